@@ -0,0 +1,182 @@
+package xmlvector
+
+import (
+	"iter"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// SetForceArray registers element names or dot paths of elements that must be always treated as array items.
+//
+// Key without dots is an element name and matches elements with that name at any depth, eg: "CD". Key with dots is a
+// path from the root element, eg: "CATALOG.CD". Parent of the matching element becomes an array even if the element
+// isn't repeated. Please note, only parent with homogeneous children may become an array, elements among mixed siblings
+// are available using Group().
+func (vec *Vector) SetForceArray(keys ...string) *Vector {
+	vec.arrForce = append(vec.arrForce[:0], keys...)
+	return vec
+}
+
+// SetNeverArray registers element names or dot paths of elements that must never be treated as array items even if
+// they're repeated. See SetForceArray for keys format. Never rules have priority over force rules.
+func (vec *Vector) SetNeverArray(keys ...string) *Vector {
+	vec.arrNever = append(vec.arrNever[:0], keys...)
+	return vec
+}
+
+// Group returns an iterator over child elements of the node with given name in document order.
+//
+// Works for both arrays and objects, thus repeated elements among mixed siblings are available as a group, eg: for
+// <r><a/><b/><a/></r> Group(r, "a") yields both "a" elements.
+func Group(node *vector.Node, name string) iter.Seq2[int, *vector.Node] {
+	return func(yield func(int, *vector.Node) bool) {
+		var i int
+		eachChild(node, childElem, func(_ int, child *vector.Node) bool {
+			if child.KeyString() != name {
+				return true
+			}
+			ok := yield(i, child)
+			i++
+			return ok
+		})
+	}
+}
+
+// Groups returns an iterator over distinct names of child elements of the node and count of elements in each group.
+//
+// Names are yielded in order of first occurrence.
+func Groups(node *vector.Node) iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {
+		kids, ends := groupChildren(node, nil, nil)
+		var start int
+		for _, end := range ends {
+			if !yield(kids[start].KeyString(), end-start) {
+				return
+			}
+			start = end
+		}
+	}
+}
+
+// Max count of groups to search linearly, see groupChildren.
+const groupsLinear = 8
+
+// Group element children of the node by name in order of the first occurrence of each name.
+//
+// Children are appended to kids ordered by groups (document order inside the group) and end offsets of groups in kids
+// are appended to ends.
+func groupChildren(node *vector.Node, kids []*vector.Node, ends []int) ([]*vector.Node, []int) {
+	var (
+		gids  []int
+		names map[string]int
+	)
+	base, gbase := len(kids), len(ends)
+	eachChild(node, childElem, func(_ int, child *vector.Node) bool {
+		kids = append(kids, child)
+		name := byteconv.B2S(child.Key().Bytes())
+		gid := -1
+		if names != nil {
+			if g, ok := names[name]; ok {
+				gid = g
+			}
+		} else {
+			for g := gbase; g < len(ends); g++ {
+				// Ends contain offset of the first child of the group until children are placed.
+				if kids[ends[g]].KeyString() == name {
+					gid = g - gbase
+					break
+				}
+			}
+		}
+		if gid == -1 {
+			gid = len(ends) - gbase
+			ends = append(ends, len(kids)-1)
+			switch {
+			case names != nil:
+				names[name] = gid
+			case gid == groupsLinear:
+				names = make(map[string]int, groupsLinear*2)
+				for g := gbase; g < len(ends); g++ {
+					names[byteconv.B2S(kids[ends[g]].Key().Bytes())] = g - gbase
+				}
+			}
+		}
+		gids = append(gids, gid)
+		return true
+	})
+	if len(ends)-gbase < 2 {
+		// Single group is already ordered.
+		for g := gbase; g < len(ends); g++ {
+			ends[g] = len(kids)
+		}
+		return kids, ends
+	}
+
+	// Count children in groups and place them by groups.
+	cnt := make([]int, len(ends)-gbase)
+	for _, gid := range gids {
+		cnt[gid]++
+	}
+	off := base
+	for g := range cnt {
+		off, cnt[g] = off+cnt[g], off
+		ends[gbase+g] = off
+	}
+	sorted := make([]*vector.Node, len(gids))
+	for i, gid := range gids {
+		sorted[cnt[gid]-base] = kids[base+i]
+		cnt[gid]++
+	}
+	copy(kids[base:], sorted)
+	return kids, ends
+}
+
+// Check if array rules are registered.
+func (vec *Vector) arrRules() bool {
+	return len(vec.arrForce) > 0 || len(vec.arrNever) > 0
+}
+
+// Append key of the node to the current path and return previous length of the path.
+func (vec *Vector) pushArrPath(node *vector.Node) int {
+	plen := len(vec.arrPath)
+	if plen > 0 {
+		vec.arrPath = append(vec.arrPath, '.')
+	}
+	vec.arrPath = append(vec.arrPath, node.Key().RawBytes()...)
+	return plen
+}
+
+// Apply array rules to the name of parent's children and return final array flag.
+//
+// homo indicates that all children of the parent have the same name.
+func (vec *Vector) checkArrRules(name string, arr, homo bool) bool {
+	for _, key := range vec.arrNever {
+		if vec.matchArrKey(key, name) {
+			return false
+		}
+	}
+	if arr || !homo {
+		return arr
+	}
+	for _, key := range vec.arrForce {
+		if vec.matchArrKey(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if key matches element name or path of the element (current path + name).
+//
+// Names may contain dots, so path is compared as a prefix of the key followed by the separator and the whole name.
+func (vec *Vector) matchArrKey(key, name string) bool {
+	if key == name {
+		return true
+	}
+	pl := len(vec.arrPath)
+	if pl == 0 || len(key) != pl+1+len(name) {
+		return false
+	}
+	return key[pl] == '.' && key[:pl] == string(vec.arrPath) && key[pl+1:] == name
+}
@@ -0,0 +1,129 @@
+package xmlvector
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestArray(t *testing.T) {
+	t.Run("array/single", func(t *testing.T) {
+		vec := NewVector()
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG", vector.TypeObject)
+		assertType(t, vec, "CATALOG.CD.TRACKS", vector.TypeObject)
+
+		vec.SetForceArray("CATALOG.CD", "TRACK")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG.CD", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.0.TITLE", "Empire Burlesque", vector.TypeString)
+		assertType(t, vec, "CATALOG.0.TRACKS.TRACK", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.0.TRACKS.0", "Tight Connection to My Heart", vector.TypeString)
+
+		vec.SetForceArray("CD")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG.CD", vector.TypeArray)
+		assertType(t, vec, "CATALOG.0.TRACKS", vector.TypeObject)
+
+		vec.SetForceArray("TRACKS.TRACK")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG", vector.TypeObject)
+		assertType(t, vec, "CATALOG.CD.TRACKS", vector.TypeObject)
+	})
+	t.Run("root/array", func(t *testing.T) {
+		vec := NewVector().SetNeverArray("CATALOG.CD")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG", vector.TypeObject)
+		assertStr(t, vec, "CATALOG.CD.TITLE", "Empire Burlesque", vector.TypeString)
+
+		vec.SetNeverArray("CD").SetForceArray("CD")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "CATALOG", vector.TypeObject)
+	})
+	t.Run("dotted", func(t *testing.T) {
+		// Rules match dotted names both by name and by path.
+		vec := NewVector()
+		for _, c := range []struct {
+			key   string
+			src   string
+			keys  []string
+			typ   vector.Type
+			never bool
+		}{
+			{"a.b", `<r><a.b>1</a.b></r>`, []string{"r"}, vector.TypeArray, false},
+			{"r.a.b", `<r><a.b>1</a.b></r>`, []string{"r"}, vector.TypeArray, false},
+			{"r.x.y.b", `<r><x.y><b>1</b></x.y></r>`, []string{"r", "x.y"}, vector.TypeArray, false},
+			{"r.x", `<r><x.y><b>1</b></x.y></r>`, []string{"r"}, vector.TypeObject, false},
+			{"x.y.a", `<x.y><a>1</a><a>2</a></x.y>`, []string{"x.y"}, vector.TypeObject, true},
+			{"a.b", `<r><a.b>1</a.b><a.b>2</a.b></r>`, []string{"r"}, vector.TypeObject, true},
+		} {
+			vec.Reset()
+			if c.never {
+				vec.SetNeverArray(c.key)
+			} else {
+				vec.SetForceArray(c.key)
+			}
+			if err := vec.ParseCopyString(c.src); err != nil {
+				t.Fatal(err)
+			}
+			if typ := vec.Root().Get(c.keys...).Type(); typ != c.typ {
+				t.Errorf("type mismatch for %s in %s, need %d got %d", c.key, c.src, c.typ, typ)
+			}
+			vec.SetForceArray().SetNeverArray()
+		}
+	})
+	t.Run("array/mixed", func(t *testing.T) {
+		vec := NewVector()
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "r", vector.TypeObject)
+		var buf []string
+		for i, node := range Group(vec.Dot("r"), "a") {
+			buf = append(buf, strconv.Itoa(i)+":"+node.String())
+		}
+		if s := strings.Join(buf, " "); s != "0:1 1:2 2:4" {
+			t.Error("group mismatch, got", s)
+		}
+		buf = buf[:0]
+		for name, cnt := range Groups(vec.Dot("r")) {
+			buf = append(buf, name+":"+strconv.Itoa(cnt))
+		}
+		if s := strings.Join(buf, " "); s != "a:3 b:1 c:1" {
+			t.Error("groups mismatch, got", s)
+		}
+		if p := vec.XPath(vec.Dot("r.c").Dot("@id")); p != "/r/c/@id" {
+			t.Error("xpath mismatch, got", p)
+		}
+
+		vec.SetForceArray("a")
+		assertParse(t, vec, nil, 0)
+		assertType(t, vec, "r", vector.TypeObject)
+
+		var w bytes.Buffer
+		_ = vec.Marshal(&w)
+		if st := getStage(getTBName(t)); !bytes.Equal(w.Bytes(), st.flat) {
+			t.Error("marshal mismatch, got", w.String())
+		}
+	})
+	t.Run("groups", func(t *testing.T) {
+		vec := NewVector()
+		var src strings.Builder
+		src.WriteString("<r>")
+		for i := 0; i < 30; i++ {
+			src.WriteString("<e" + strconv.Itoa(i%12) + "/>")
+		}
+		src.WriteString("</r>")
+		if err := vec.ParseString(src.String()); err != nil {
+			t.Fatal(err)
+		}
+		var buf []string
+		for name, cnt := range Groups(vec.Dot("r")) {
+			buf = append(buf, name+":"+strconv.Itoa(cnt))
+		}
+		if s := strings.Join(buf, " "); s != "e0:3 e1:3 e2:3 e3:3 e4:3 e5:3 e6:2 e7:2 e8:2 e9:2 e10:2 e11:2" {
+			t.Error("groups mismatch, got", s)
+		}
+	})
+}
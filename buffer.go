@@ -0,0 +1,51 @@
+package xmlvector
+
+import "github.com/koykov/byteconv"
+
+const bufChunkSize = 1024
+
+// Chunked storage of bytes added to the vector after parsing (mutations, builder).
+//
+// Unlike vector's buffer, chunks never grow over their capacity, so addresses of stored bytes remain valid until reset.
+type chunkBuf struct {
+	chunks [][]byte
+	// Index of the current chunk.
+	c int
+}
+
+// Copy p to the storage and return stored copy.
+func (b *chunkBuf) bufferize(p []byte) []byte {
+	return b.bufferizeString(byteconv.B2S(p))
+}
+
+// Copy s to the storage and return stored copy.
+func (b *chunkBuf) bufferizeString(s string) []byte {
+	n := len(s)
+	if n == 0 {
+		return nil
+	}
+	for ; b.c < len(b.chunks); b.c++ {
+		if chunk := b.chunks[b.c]; cap(chunk)-len(chunk) >= n {
+			break
+		}
+	}
+	if b.c == len(b.chunks) {
+		size := bufChunkSize
+		if n > size {
+			size = n
+		}
+		b.chunks = append(b.chunks, make([]byte, 0, size))
+	}
+	chunk := b.chunks[b.c]
+	off := len(chunk)
+	chunk = append(chunk, s...)
+	b.chunks[b.c] = chunk
+	return chunk[off:]
+}
+
+func (b *chunkBuf) reset() {
+	for i := 0; i < len(b.chunks); i++ {
+		b.chunks[i] = b.chunks[i][:0]
+	}
+	b.c = 0
+}
@@ -0,0 +1,244 @@
+package xmlvector
+
+import (
+	"github.com/koykov/vector"
+)
+
+// Builder makes XML document from scratch in the vector.
+//
+// Builder is fluent: each method returns the builder itself and the first error stops further building, see Err.
+// Resulting vector is equal to parsed one: it may be queried using Dot and serialized using Marshal/Beautify.
+//
+// Usage example:
+//
+//	vec := xmlvector.Acquire()
+//	b := xmlvector.NewBuilder(vec)
+//	b.Element("catalog").Attr("id", "1").
+//		Element("cd").Text("Empire Burlesque").End().
+//		Element("cd").CDATA("Hide your heart").End().
+//		End()
+//	err := b.Finish()
+type Builder struct {
+	vec *Vector
+	// Stack of indexes of open elements.
+	stack []int
+	// Root element is already built.
+	root bool
+	err  error
+}
+
+// NewBuilder makes new builder over vec. Vector resets before building.
+func NewBuilder(vec *Vector) *Builder {
+	b := &Builder{}
+	b.Reset(vec)
+	return b
+}
+
+// Reset vector and prepare builder to make new document.
+func (b *Builder) Reset(vec *Vector) *Builder {
+	b.vec, b.stack, b.root, b.err = vec, b.stack[:0], false, nil
+	vec.Reset()
+	// Built document has no source, but vector binds nodes on source initialization.
+	_ = vec.SetSrc(bPairs, false)
+	root, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	root.SetOffset(vec.Index.Len(1))
+	vec.ReleaseNode(i, root)
+	return b
+}
+
+// Prolog adds attribute to document prolog. Must be called before the root element.
+//
+// Prolog without attributes gets default version="1.0".
+func (b *Builder) Prolog(name, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.root {
+		b.err = ErrAttrOrder
+		return b
+	}
+	b.attr(0, name, value)
+	return b
+}
+
+// Element opens new child element of the current element.
+func (b *Builder) Element(name string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	vec := b.vec
+	if len(b.stack) == 0 {
+		if b.root {
+			b.err = ErrMultiRoot
+			return b
+		}
+		b.root = true
+		if !hasAttrs(vec.Root()) {
+			b.attr(0, "version", "1.0")
+		}
+	} else if b.current().Value().Len() > 0 {
+		b.err = ErrMixedContent
+		return b
+	}
+	node, i := b.acquire(vector.TypeObject)
+	depth := node.Depth()
+	node.SetOffset(vec.Index.Len(depth + 1))
+	node.Key().Init(vec.cbuf.bufferizeString(name), 0, len(name))
+	vec.ReleaseNode(i, node)
+	b.stack = append(b.stack, i)
+	return b
+}
+
+// Attr adds attribute to the current element. Must be called before element's content.
+func (b *Builder) Attr(name, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.stack) == 0 {
+		b.err = ErrNoOpenElem
+		return b
+	}
+	node := b.current()
+	if node.Value().Len() > 0 {
+		b.err = ErrAttrOrder
+		return b
+	}
+	for range Children(node) {
+		b.err = ErrAttrOrder
+		return b
+	}
+	b.attr(b.top(), name, value)
+	node = b.current()
+	node.Key().SetBit(flagAttr, true)
+	return b
+}
+
+// Text sets text content of the current element. Text will be escaped on output.
+//
+// Repeated call replaces the text.
+func (b *Builder) Text(text string) *Builder {
+	return b.text(text, false)
+}
+
+// CDATA sets content of the current element as CDATA section. Content is written as is on output.
+//
+// Repeated call replaces the content.
+func (b *Builder) CDATA(text string) *Builder {
+	return b.text(text, true)
+}
+
+// Comment adds comment to the current element (or to the document if no element is open).
+//
+// Comments aren't elements, so Children, Group and others skip them, but they're written by Marshal/Beautify.
+// Element containing comments never becomes an array.
+func (b *Builder) Comment(text string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	vec := b.vec
+	if len(b.stack) > 0 && b.current().Value().Len() > 0 {
+		b.err = ErrMixedContent
+		return b
+	}
+	node, i := b.acquire(vector.TypeString)
+	node.Key().SetBit(flagComment, true)
+	setValue(node.Value(), vec.cbuf.bufferizeString(text))
+	setRange(node, 0, 0)
+	vec.ReleaseNode(i, node)
+	return b
+}
+
+// End closes the current element.
+func (b *Builder) End() *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.stack) == 0 {
+		b.err = ErrNoOpenElem
+		return b
+	}
+	vec, node := b.vec, b.current()
+	b.stack = b.stack[:len(b.stack)-1]
+	vec.closeNode(node)
+	switch {
+	case node.Value().Len() > 0:
+		if !node.Key().CheckBit(flagAttr) {
+			node.SetType(vector.TypeString)
+		}
+	default:
+		vec.checkArr(node)
+	}
+	return b
+}
+
+// Finish closes all open elements and returns first error occurred during building.
+func (b *Builder) Finish() error {
+	for b.err == nil && len(b.stack) > 0 {
+		b.End()
+	}
+	if b.err == nil && !b.root {
+		b.err = ErrNoRoot
+	}
+	return b.err
+}
+
+// Err returns first error occurred during building.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// Get index of the current element node or document root node if no element is open.
+func (b *Builder) top() int {
+	if len(b.stack) == 0 {
+		return 0
+	}
+	return b.stack[len(b.stack)-1]
+}
+
+// Get the current element node or document root node if no element is open.
+func (b *Builder) current() *vector.Node {
+	return b.vec.NodeAt(b.top())
+}
+
+// Acquire new child node of the current element.
+//
+// Parent's limit is set using its index, since acquiring may grow nodes array and make parent pointer stale.
+func (b *Builder) acquire(typ vector.Type) (*vector.Node, int) {
+	vec, pi := b.vec, b.top()
+	depth := vec.NodeAt(pi).Depth() + 1
+	node, i := vec.AcquireNodeWithType(depth, typ)
+	vec.NodeAt(pi).SetLimit(vec.Index.Len(depth))
+	vec.setParent(i, pi)
+	return node, i
+}
+
+// Set text or CDATA content of the current element.
+func (b *Builder) text(text string, cdata bool) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.stack) == 0 {
+		b.err = ErrNoOpenElem
+		return b
+	}
+	node := b.current()
+	if !eachChild(node, childElem|childComment, func(int, *vector.Node) bool { return false }) {
+		b.err = ErrMixedContent
+		return b
+	}
+	setValue(node.Value(), b.vec.cbuf.bufferizeString(text))
+	node.Value().SetBit(flagCDATA, cdata)
+	return b
+}
+
+// Add attribute node to the node with index pi.
+func (b *Builder) attr(pi int, name, value string) {
+	vec := b.vec
+	depth := vec.NodeAt(pi).Depth() + 1
+	attr, i := vec.AcquireNodeWithType(depth, vector.TypeAttribute)
+	vec.NodeAt(pi).SetLimit(vec.Index.Len(depth))
+	vec.setParent(i, pi)
+	attr.Key().Init(vec.cbuf.bufferizeString(name), 0, len(name))
+	setValue(attr.Value(), vec.cbuf.bufferizeString(value))
+	vec.ReleaseNode(i, attr)
+}
@@ -0,0 +1,122 @@
+package xmlvector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestBuilder(t *testing.T) {
+	assertEqual := func(t *testing.T, vec *Vector) {
+		orig := NewVector()
+		assertParse(t, orig, nil, 0)
+		var a, b bytes.Buffer
+		_ = orig.Marshal(&a)
+		_ = vec.Marshal(&b)
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Errorf("marshal mismatch, need\n%s\ngot\n%s", a.String(), b.String())
+		}
+	}
+	t.Run("root/cdata", func(t *testing.T) {
+		vec := NewVector()
+		err := NewBuilder(vec).Prolog("version", "1.0").Prolog("encoding", "utf-8").
+			Element("movie").
+			Element("raw").Text("Marquis Warren").End().
+			Element("cdata").CDATA(`<strong>Main protagonist<strong> of "The Hateful Eight"`).
+			Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "movie.raw", "Marquis Warren", vector.TypeString)
+		assertStr(t, vec, "movie.cdata", `<strong>Main protagonist<strong> of "The Hateful Eight"`, vector.TypeString)
+		assertEqual(t, vec)
+	})
+	t.Run("root/attr", func(t *testing.T) {
+		vec := NewVector()
+		err := NewBuilder(vec).Prolog("version", "1.0").Prolog("encoding", "UTF-8").
+			Element("root").Attr("title", "Foo").Attr("descr", "Bar").Attr("arg0", "qwe").Attr("arg1", "15").
+			Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "root@arg1", "15", vector.TypeAttribute)
+		assertEqual(t, vec)
+	})
+	t.Run("array", func(t *testing.T) {
+		vec := NewVector()
+		b := NewBuilder(vec).Element("list").Comment(" items ")
+		for _, s := range []string{"a & b", "c", "d"} {
+			b.Element("item").Text(s).End()
+		}
+		if err := b.End().Finish(); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "list", vector.TypeObject)
+		assertStr(t, vec, "list.item", "a & b", vector.TypeString)
+		assertStr(t, vec, "@version", "1.0", vector.TypeAttribute)
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		expect := `<?xml version="1.0"?><list><!-- items --><item>a &amp; b</item><item>c</item><item>d</item></list>`
+		if buf.String() != expect {
+			t.Error("marshal mismatch, got", buf.String())
+		}
+	})
+	t.Run("root/array", func(t *testing.T) {
+		orig := NewVector()
+		assertParse(t, orig, nil, 0)
+		vec := NewVector()
+		b := NewBuilder(vec).Prolog("version", "1.0").Prolog("encoding", "UTF-8").Element("CATALOG")
+		for _, cd := range Children(orig.Dot("CATALOG")) {
+			b.Element("CD")
+			for _, field := range Children(cd) {
+				b.Element(field.KeyString()).Text(field.String()).End()
+			}
+			b.End()
+		}
+		if err := b.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "CATALOG", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.25.TITLE", "Unchain my heart", vector.TypeString)
+		assertEqual(t, vec)
+	})
+	t.Run("errors", func(t *testing.T) {
+		vec := NewVector()
+		if err := NewBuilder(vec).Element("a").End().Element("b").Finish(); err != ErrMultiRoot {
+			t.Error("error mismatch, got", err)
+		}
+		if err := NewBuilder(vec).Element("a").Text("foo").Element("b").Finish(); err != ErrMixedContent {
+			t.Error("error mismatch, got", err)
+		}
+		if err := NewBuilder(vec).Element("a").Element("b").End().Attr("id", "1").Finish(); err != ErrAttrOrder {
+			t.Error("error mismatch, got", err)
+		}
+		if err := NewBuilder(vec).End().Finish(); err != ErrNoOpenElem {
+			t.Error("error mismatch, got", err)
+		}
+		if err := NewBuilder(vec).Finish(); err != ErrNoRoot {
+			t.Error("error mismatch, got", err)
+		}
+	})
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	b.ReportAllocs()
+	bld := &Builder{}
+	for i := 0; i < b.N; i++ {
+		vec := Acquire()
+		bld.Reset(vec).Element("CATALOG")
+		for j := 0; j < 26; j++ {
+			bld.Element("CD").
+				Element("TITLE").Text("Empire Burlesque").End().
+				Element("ARTIST").Text("Bob Dylan").End().
+				Element("YEAR").Text("1985").End().
+				End()
+		}
+		if err := bld.Finish(); err != nil {
+			b.Fatal(err)
+		}
+		Release(vec)
+	}
+}
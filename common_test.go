@@ -99,3 +99,20 @@ func assertStr(tb testing.TB, vec *Vector, path, expect string, typ vector.Type)
 		tb.Error("node value mismatch, need", expect, "got", v)
 	}
 }
+
+var errWrite = errors.New("write failure")
+
+// Writer fails after n bytes.
+type failWriter struct {
+	n int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
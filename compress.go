@@ -0,0 +1,127 @@
+package xmlvector
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// Decompressor wraps reader of compressed data with reader of decompressed data. Reader implementing io.Closer will
+// be closed after reading.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// Registered decompressor.
+type decompressorEntry struct {
+	magic []byte
+	dec   Decompressor
+}
+
+// Max length of magic bytes of compressed data.
+const maxMagic = 8
+
+var (
+	decompMux sync.RWMutex
+	decompReg []decompressorEntry
+)
+
+func init() {
+	RegisterDecompressor([]byte{0x1f, 0x8b}, decompressGzip)
+	// Zlib header with deflate method, 32K window and all compression levels.
+	for _, flg := range []byte{0x01, 0x5e, 0x9c, 0xda} {
+		RegisterDecompressor([]byte{0x78, flg}, decompressZlib)
+	}
+	RegisterDecompressor([]byte("BZh"), decompressBzip2)
+}
+
+// RegisterDecompressor registers decompressor of data starting with magic bytes (up to 8 bytes), eg: zstd frame
+// magic 28 b5 2f fd.
+//
+// Decompressor of already registered magic will be overwritten, nil decompressor removes the registration.
+func RegisterDecompressor(magic []byte, dec Decompressor) {
+	if len(magic) == 0 || len(magic) > maxMagic {
+		return
+	}
+	decompMux.Lock()
+	defer decompMux.Unlock()
+	for i := 0; i < len(decompReg); i++ {
+		if bytes.Equal(decompReg[i].magic, magic) {
+			if dec == nil {
+				decompReg = append(decompReg[:i], decompReg[i+1:]...)
+				return
+			}
+			decompReg[i].dec = dec
+			return
+		}
+	}
+	if dec == nil {
+		return
+	}
+	decompReg = append(decompReg, decompressorEntry{magic: append([]byte(nil), magic...), dec: dec})
+}
+
+// SetDecompress enables compressed source detection in ParseReader and ParseFile. Source is decompressed if it
+// starts with magic bytes of registered decompressor (gzip, zlib and bzip2 are available out of box), otherwise it's
+// parsed as is.
+func (vec *Vector) SetDecompress(value bool) *Vector {
+	vec.decompress = value
+	return vec
+}
+
+// Sniff compression of the data and wrap r with decompressor. Returned closer (if any) releases decompressor.
+func decompressReader(r io.Reader) (io.Reader, io.Closer, error) {
+	var head [maxMagic]byte
+	n, err := io.ReadFull(r, head[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, nil, err
+	}
+	src := io.MultiReader(bytes.NewReader(head[:n]), r)
+	dec := lookupDecompressor(head[:n])
+	if dec == nil {
+		return fullReader{r: src}, nil, nil
+	}
+	dr, err := dec(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, _ := dr.(io.Closer)
+	return fullReader{r: dr}, c, nil
+}
+
+func lookupDecompressor(head []byte) Decompressor {
+	decompMux.RLock()
+	defer decompMux.RUnlock()
+	for i := 0; i < len(decompReg); i++ {
+		if bytes.HasPrefix(head, decompReg[i].magic) {
+			return decompReg[i].dec
+		}
+	}
+	return nil
+}
+
+func decompressGzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func decompressZlib(r io.Reader) (io.Reader, error) {
+	return zlib.NewReader(r)
+}
+
+func decompressBzip2(r io.Reader) (io.Reader, error) {
+	return bzip2.NewReader(r), nil
+}
+
+// Reader filling the buffer completely unless the end of data, since base ParseReader stops at short read.
+type fullReader struct {
+	r io.Reader
+}
+
+func (r fullReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(r.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,104 @@
+package xmlvector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestDecompress(t *testing.T) {
+	origin, _ := os.ReadFile("testdata/root/array.xml")
+	// Source bigger than the read chunk of the base reader.
+	origin = bytes.Repeat(origin, 10)
+	origin = append([]byte("<root>"), append(bytes.ReplaceAll(origin, []byte(`<?xml version="1.0" encoding="UTF-8"?>`), nil), "</root>"...)...)
+
+	compress := func(fn func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := fn(&buf)
+		_, _ = w.Write(origin)
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	var expect bytes.Buffer
+	ref := NewVector()
+	_ = ref.Parse(origin)
+	_ = ref.Marshal(&expect)
+	assertCatalog := func(t *testing.T, vec *Vector) {
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		if buf.String() != expect.String() {
+			t.Error("decompressed source mismatch")
+		}
+	}
+
+	vec := NewVector().SetDecompress(true)
+	stages := []struct {
+		name string
+		src  []byte
+	}{
+		{"plain", origin},
+		{"gzip", compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{"zlib", compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+	}
+	for _, st := range stages {
+		t.Run(st.name, func(t *testing.T) {
+			vec.Reset()
+			if err := vec.ParseReader(bytes.NewReader(st.src)); err != nil {
+				t.Fatal(err)
+			}
+			assertCatalog(t, vec)
+		})
+	}
+	t.Run("file", func(t *testing.T) {
+		for _, path := range []string{"testdata/compress/array.xml.gz", "testdata/compress/array.xml.bz2"} {
+			vec.Reset()
+			if err := vec.ParseFile(path); err != nil {
+				t.Fatal(path, err)
+			}
+			assertType(t, vec, "CATALOG.CD", vector.TypeArray)
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		vec := NewVector()
+		if err := vec.ParseFile("testdata/compress/array.xml.gz"); err == nil {
+			t.Error("compressed source must fail without decompression")
+		}
+	})
+	t.Run("register", func(t *testing.T) {
+		// Fake compression: magic followed by plain data.
+		magic := []byte("XVZ\x00")
+		RegisterDecompressor(magic, func(r io.Reader) (io.Reader, error) {
+			_, err := io.ReadFull(r, make([]byte, len(magic)))
+			return r, err
+		})
+		t.Cleanup(func() { RegisterDecompressor(magic, nil) })
+		src := append(magic, origin...)
+		vec.Reset()
+		if err := vec.ParseReader(bytes.NewReader(src)); err != nil {
+			t.Fatal(err)
+		}
+		assertCatalog(t, vec)
+
+		RegisterDecompressor(magic, nil)
+		if lookupDecompressor(src) != nil {
+			t.Error("decompressor must be removed")
+		}
+		vec.Reset()
+		if err := vec.ParseReader(bytes.NewReader(src)); err == nil {
+			t.Error("source must fail without decompressor")
+		}
+	})
+	t.Run("context", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseReaderContext(context.Background(), bytes.NewReader(stages[1].src)); err != nil {
+			t.Fatal(err)
+		}
+		assertCatalog(t, vec)
+	})
+}
@@ -0,0 +1,82 @@
+package xmlvector
+
+import (
+	"context"
+	"io"
+
+	"github.com/koykov/vector"
+)
+
+// Context is checked once per ctxSteps elements.
+const ctxSteps = 256
+
+// ParseContext parses source bytes. Parsing stops with ctx.Err() as soon as the context is done.
+//
+// Vector may be reset and reused after cancelled parsing.
+func (vec *Vector) ParseContext(ctx context.Context, s []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	vec.setCtx(ctx)
+	defer vec.setCtx(nil)
+	return vec.parse(s, false, false)
+}
+
+// ParseReaderContext reads source from r and parse it. Context is checked before each read and during parsing, see
+// ParseContext.
+func (vec *Vector) ParseReaderContext(ctx context.Context, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if vec.decompress {
+		dr, c, err := decompressReader(ctxReader{ctx: ctx, r: r})
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			defer func() { _ = c.Close() }()
+		}
+		r = dr
+	}
+	err := vec.Vector.ParseReader(ctxReader{ctx: ctx, r: r})
+	if err == vector.ErrNotImplement {
+		// Base reader stops at short read, so the context must be checked explicitly.
+		err = ctx.Err()
+	}
+	if err != nil {
+		// Base Reset keeps the buffer of vector without nodes, so the source read so far is dropped here.
+		vec.BufReplaceWith(vec.Buf()[:0])
+		return err
+	}
+	vec.setCtx(ctx)
+	defer vec.setCtx(nil)
+	return vec.parse(vec.Buf(), false, false)
+}
+
+func (vec *Vector) setCtx(ctx context.Context) {
+	vec.ctx, vec.csteps = ctx, 0
+}
+
+// Check context of the parsing every ctxSteps calls.
+func (vec *Vector) checkCtx() error {
+	if vec.ctx == nil {
+		return nil
+	}
+	if vec.csteps++; vec.csteps%ctxSteps != 0 {
+		return nil
+	}
+	return vec.ctx.Err()
+}
+
+// Reader checking the context before each read.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,73 @@
+package xmlvector
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+// Reader cancelling the context at the end of source.
+type cancelReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (r cancelReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n < len(p) || err == io.EOF {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestContext(t *testing.T) {
+	src := "<feed>" + strings.Repeat("<item>x</item>", 1000) + "</feed>"
+	vec := NewVector()
+	t.Run("done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		vec.Reset()
+		if err := vec.ParseContext(ctx, []byte(src)); err != context.Canceled {
+			t.Errorf("error mismatch, need %v got %v", context.Canceled, err)
+		}
+	})
+	t.Run("parse", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		vec.Reset()
+		// Skip the check before parsing to cancel in the middle.
+		vec.setCtx(ctx)
+		err := vec.ParseCopyString(src)
+		vec.setCtx(nil)
+		if err != context.Canceled {
+			t.Fatalf("error mismatch, need %v got %v", context.Canceled, err)
+		}
+		if off := vec.ErrorOffset(); off == 0 || off >= len(src) {
+			t.Errorf("unexpected error offset %d", off)
+		}
+
+		// Vector must be reusable after cancelling.
+		vec.Reset()
+		if err = vec.ParseContext(context.Background(), []byte(src)); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "feed", vector.TypeArray)
+	})
+	t.Run("reader", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		vec.Reset()
+		err := vec.ParseReaderContext(ctx, cancelReader{r: strings.NewReader(src), cancel: cancel})
+		if err != context.Canceled {
+			t.Errorf("error mismatch, need %v got %v", context.Canceled, err)
+		}
+		vec.Reset()
+		if err = vec.ParseReaderContext(context.Background(), strings.NewReader(src)); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "feed.999", "x", vector.TypeString)
+	})
+}
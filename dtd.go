@@ -0,0 +1,130 @@
+package xmlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+)
+
+// Attribute declared in DTD with tokenized type (ID, NMTOKENS, enumeration, ...).
+type dtdAttr struct {
+	elem, name []byte
+}
+
+var (
+	bDTAttList  = []byte("<!ATTLIST")
+	bDTCDATA    = []byte("CDATA")
+	bDTNotation = []byte("NOTATION")
+	bDTFixed    = []byte("#FIXED")
+)
+
+// Find the end of doctype declaration (p starts with "<!DOCTYPE") considering internal subset, quoted literals and
+// comments.
+//
+// Returns offset after closing '>' and boundaries of the internal subset (-1 if not found).
+func dtdBounds(p []byte) (end, sub0, sub1 int) {
+	sub0, sub1 = -1, -1
+	for i := lenDTOpen; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '"', '\'':
+			j := bytes.IndexByte(p[i+1:], c)
+			if j == -1 {
+				return -1, -1, -1
+			}
+			i += j + 1
+		case '<':
+			if bytes.HasPrefix(p[i:], bCommentOpen) {
+				j := bytealg.IndexAtBytes(p, bCommentClose, i+4)
+				if j == -1 {
+					return -1, -1, -1
+				}
+				i = j + 2
+			}
+		case '[':
+			if sub0 == -1 {
+				sub0 = i + 1
+			}
+		case ']':
+			if sub0 != -1 && sub1 == -1 {
+				sub1 = i
+			}
+		case '>':
+			if sub0 == -1 || sub1 != -1 {
+				return i + 1, sub0, sub1
+			}
+		}
+	}
+	return -1, -1, -1
+}
+
+// Scan internal DTD subset and collect attributes declared with tokenized types.
+func (vec *Vector) parseDTD(p []byte) {
+	for {
+		i := bytes.Index(p, bDTAttList)
+		if i == -1 {
+			return
+		}
+		p = p[i+len(bDTAttList):]
+		var elem, name, typ, def []byte
+		if elem, p = dtdToken(p); len(elem) == 0 || elem[0] == '>' {
+			continue
+		}
+		for {
+			if name, p = dtdToken(p); len(name) == 0 || name[0] == '>' {
+				break
+			}
+			typ, p = dtdToken(p)
+			if bytes.Equal(typ, bDTNotation) {
+				// Skip notations list.
+				_, p = dtdToken(p)
+			}
+			if def, p = dtdToken(p); bytes.Equal(def, bDTFixed) {
+				_, p = dtdToken(p)
+			}
+			if len(typ) > 0 && typ[0] != '>' && !bytes.Equal(typ, bDTCDATA) {
+				vec.dtdTok = append(vec.dtdTok, dtdAttr{elem: elem, name: name})
+			}
+		}
+	}
+}
+
+// Get next token of the declaration: name, quoted literal, parenthesized group or '>'.
+func dtdToken(p []byte) ([]byte, []byte) {
+	var i int
+	for i < len(p) && skipTable[p[i]] {
+		i++
+	}
+	if i == len(p) {
+		return nil, nil
+	}
+	p = p[i:]
+	var j int
+	switch c := p[0]; c {
+	case '>':
+		j = 1
+	case '"', '\'':
+		if j = bytes.IndexByte(p[1:], c) + 2; j == 1 {
+			j = len(p)
+		}
+	case '(':
+		if j = bytes.IndexByte(p, ')') + 1; j == 0 {
+			j = len(p)
+		}
+	default:
+		for j < len(p) && !skipTable[p[j]] && p[j] != '>' && p[j] != '(' {
+			j++
+		}
+	}
+	return p[:j], p[j:]
+}
+
+// Check if attribute of the element is declared with tokenized type.
+func (vec *Vector) isTokAttr(elem, name []byte) bool {
+	for i := 0; i < len(vec.dtdTok); i++ {
+		a := &vec.dtdTok[i]
+		if bytes.Equal(a.elem, elem) && bytes.Equal(a.name, name) {
+			return true
+		}
+	}
+	return false
+}
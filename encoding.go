@@ -0,0 +1,411 @@
+package xmlvector
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+)
+
+// Decoder transcodes src from some encoding to UTF-8 and appends result to dst.
+type Decoder func(dst, src []byte) ([]byte, error)
+
+// Registered decoder.
+type decoderEntry struct {
+	// Canonical name of the encoding.
+	name string
+	// Nil decoder means UTF-8 compatible encoding.
+	dec Decoder
+	// Encoding is UTF-16 (isn't ASCII compatible).
+	utf16 bool
+}
+
+var (
+	decMux sync.RWMutex
+	decReg = map[string]decoderEntry{}
+
+	bEncoding = []byte("encoding")
+	bFmt      = []byte(" \t\r\n")
+	bUTF8     = []byte("UTF-8")
+)
+
+const (
+	encUTF8    = "utf-8"
+	encUTF16   = "utf-16"
+	encUTF16LE = "utf-16le"
+	encUTF16BE = "utf-16be"
+)
+
+func init() {
+	RegisterDecoder(nil, encUTF8, "utf8", "us-ascii", "ascii")
+	RegisterDecoder(DecodeLatin1, "iso-8859-1", "iso8859-1", "latin1", "l1")
+	RegisterDecoder(DecodeLatin2, "iso-8859-2", "iso8859-2", "latin2", "l2")
+	RegisterDecoder(DecodeWindows1250, "windows-1250", "cp1250")
+	RegisterDecoder(DecodeWindows1251, "windows-1251", "cp1251")
+	RegisterDecoder(DecodeWindows1252, "windows-1252", "cp1252")
+	RegisterDecoder(DecodeKOI8R, "koi8-r", "koi8r")
+	RegisterDecoder(DecodeUTF16, encUTF16, "utf16", "ucs-2")
+	RegisterDecoder(DecodeUTF16LE, encUTF16LE)
+	RegisterDecoder(DecodeUTF16BE, encUTF16BE)
+}
+
+// RegisterDecoder registers decoder for encoding names (case-insensitive). The first name is a canonical name of the
+// encoding, see Vector.Encoding. Nil decoder means that encoding is compatible with UTF-8 and doesn't need transcoding.
+//
+// Decoders of already registered names will be overwritten.
+func RegisterDecoder(dec Decoder, names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	decMux.Lock()
+	defer decMux.Unlock()
+	e := decoderEntry{name: strings.ToLower(names[0]), dec: dec}
+	e.utf16 = strings.HasPrefix(e.name, encUTF16)
+	for i := 0; i < len(names); i++ {
+		decReg[strings.ToLower(names[i])] = e
+	}
+}
+
+// SetEncoding sets encoding of source data overriding encoding declared in prolog or autodetected. Byte order mark
+// (if present) still has priority.
+//
+// Empty string means encoding from prolog (or UTF-8 if it isn't declared). Please note, source positions (see
+// SetTrackPos) of transcoded data refer to UTF-8 representation without byte order mark.
+func (vec *Vector) SetEncoding(enc string) *Vector {
+	vec.encOverride = enc
+	return vec
+}
+
+// Encoding returns canonical name of the source data encoding (declared, detected or set), eg: "windows-1251" or
+// "utf-16le".
+//
+// Source data in non UTF-8 encoding transcodes to UTF-8 before parsing, so prolog's encoding attribute contains
+// "UTF-8" after parsing.
+func (vec *Vector) Encoding() string {
+	if len(vec.enc) == 0 {
+		return encUTF8
+	}
+	return vec.enc
+}
+
+// Check source encoding and transcode it to UTF-8 if needed.
+//
+// Byte order mark has the highest priority, next encoding set by SetEncoding, UTF-16 autodetection (see XML spec,
+// appendix F) and then encoding declared in prolog. Returns source to parse and true if source was transcoded.
+func (vec *Vector) decode(s []byte) ([]byte, bool, error) {
+	vec.enc = ""
+	det, bom := detectEncoding(s)
+	s = s[bom:]
+	var name []byte
+	switch {
+	case bom > 0:
+		name = byteconv.S2B(det)
+	case len(vec.encOverride) > 0:
+		name = byteconv.S2B(vec.encOverride)
+	case len(det) > 0:
+		name = byteconv.S2B(det)
+	default:
+		if name = prologEncoding(s); len(name) == 0 {
+			return s, false, nil
+		}
+	}
+	e, ok := lookupDecoder(name)
+	if !ok {
+		return s, false, ErrUnknownEncoding
+	}
+	if e.utf16 && len(det) == 0 && len(vec.encOverride) == 0 {
+		// Prolog declares UTF-16 but source is readable as ASCII, so declaration is wrong.
+		e = decoderEntry{name: encUTF8}
+	}
+	vec.enc = e.name
+	if e.dec == nil {
+		return s, false, nil
+	}
+	var err error
+	if vec.tbuf, err = e.dec(vec.tbuf[:0], s); err != nil {
+		return s, false, err
+	}
+	return vec.tbuf, true, nil
+}
+
+// Detect encoding using byte order mark or the first characters (expected "<?") of the source.
+//
+// Returns canonical encoding name and length of BOM.
+func detectEncoding(s []byte) (string, int) {
+	if len(s) < 2 {
+		return "", 0
+	}
+	switch {
+	case len(s) >= 3 && s[0] == 0xEF && s[1] == 0xBB && s[2] == 0xBF:
+		return encUTF8, 3
+	case s[0] == 0xFE && s[1] == 0xFF:
+		return encUTF16BE, 2
+	case s[0] == 0xFF && s[1] == 0xFE:
+		return encUTF16LE, 2
+	case len(s) >= 4 && s[0] == 0 && s[1] == '<' && s[2] == 0 && s[3] == '?':
+		return encUTF16BE, 0
+	case len(s) >= 4 && s[0] == '<' && s[1] == 0 && s[2] == '?' && s[3] == 0:
+		return encUTF16LE, 0
+	}
+	return "", 0
+}
+
+// Set encoding attribute of the prolog to UTF-8 after transcoding.
+func (vec *Vector) fixEncoding() {
+	for _, attr := range Attrs(vec.Root()) {
+		if bytes.Equal(attr.Key().RawBytes(), bEncoding) {
+			setValue(attr.Value(), bUTF8)
+			return
+		}
+	}
+}
+
+// Find decoder by encoding name.
+func lookupDecoder(name []byte) (decoderEntry, bool) {
+	var buf [32]byte
+	if len(name) > len(buf) {
+		return decoderEntry{}, false
+	}
+	lname := buf[:len(name)]
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lname[i] = c
+	}
+	decMux.RLock()
+	e, ok := decReg[string(lname)]
+	decMux.RUnlock()
+	return e, ok
+}
+
+// Get value of encoding attribute from prolog instruction without parsing.
+func prologEncoding(s []byte) []byte {
+	s = bytealg.TrimLeft(s, bFmt)
+	if !bytes.HasPrefix(s, bPrologOpen) {
+		return nil
+	}
+	if p := bytes.Index(s, bPrologClose); p != -1 {
+		s = s[:p]
+	}
+	p := bytes.Index(s, bEncoding)
+	if p == -1 {
+		return nil
+	}
+	s = bytealg.TrimLeft(s[p+len(bEncoding):], bFmt)
+	if len(s) == 0 || s[0] != '=' {
+		return nil
+	}
+	s = bytealg.TrimLeft(s[1:], bFmt)
+	if len(s) == 0 || (s[0] != '"' && s[0] != '\'') {
+		return nil
+	}
+	if p = bytes.IndexByte(s[1:], s[0]); p == -1 {
+		return nil
+	}
+	return s[1 : p+1]
+}
+
+// DecodeLatin1 transcodes ISO-8859-1 src to UTF-8 and appends result to dst.
+func DecodeLatin1(dst, src []byte) ([]byte, error) {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; c < utf8.RuneSelf {
+			dst = append(dst, c)
+		} else {
+			dst = utf8.AppendRune(dst, rune(c))
+		}
+	}
+	return dst, nil
+}
+
+// DecodeLatin2 transcodes ISO-8859-2 src to UTF-8 and appends result to dst.
+func DecodeLatin2(dst, src []byte) ([]byte, error) {
+	return decodeSingleByte(dst, src, &iso88592), nil
+}
+
+// DecodeWindows1250 transcodes windows-1250 src to UTF-8 and appends result to dst.
+func DecodeWindows1250(dst, src []byte) ([]byte, error) {
+	return decodeSingleByte(dst, src, &cp1250), nil
+}
+
+// DecodeWindows1251 transcodes windows-1251 src to UTF-8 and appends result to dst.
+func DecodeWindows1251(dst, src []byte) ([]byte, error) {
+	return decodeSingleByte(dst, src, &cp1251), nil
+}
+
+// DecodeWindows1252 transcodes windows-1252 src to UTF-8 and appends result to dst.
+func DecodeWindows1252(dst, src []byte) ([]byte, error) {
+	return decodeSingleByte(dst, src, &cp1252), nil
+}
+
+// DecodeKOI8R transcodes KOI8-R src to UTF-8 and appends result to dst.
+func DecodeKOI8R(dst, src []byte) ([]byte, error) {
+	return decodeSingleByte(dst, src, &koi8r), nil
+}
+
+// DecodeUTF16 transcodes UTF-16 src to UTF-8 and appends result to dst. Byte order detects using BOM, big-endian is
+// default.
+func DecodeUTF16(dst, src []byte) ([]byte, error) {
+	if len(src) >= 2 && src[0] == 0xFF && src[1] == 0xFE {
+		return decodeUTF16(dst, src[2:], false), nil
+	}
+	if len(src) >= 2 && src[0] == 0xFE && src[1] == 0xFF {
+		src = src[2:]
+	}
+	return decodeUTF16(dst, src, true), nil
+}
+
+// DecodeUTF16LE transcodes UTF-16 (little-endian) src to UTF-8 and appends result to dst.
+func DecodeUTF16LE(dst, src []byte) ([]byte, error) {
+	return decodeUTF16(dst, src, false), nil
+}
+
+// DecodeUTF16BE transcodes UTF-16 (big-endian) src to UTF-8 and appends result to dst.
+func DecodeUTF16BE(dst, src []byte) ([]byte, error) {
+	return decodeUTF16(dst, src, true), nil
+}
+
+// Transcode UTF-16 src. Invalid surrogates and trailing odd byte replace with U+FFFD.
+func decodeUTF16(dst, src []byte, be bool) []byte {
+	unit := func(i int) rune {
+		if be {
+			return rune(src[i])<<8 | rune(src[i+1])
+		}
+		return rune(src[i+1])<<8 | rune(src[i])
+	}
+	n := len(src) &^ 1
+	for i := 0; i < n; i += 2 {
+		r := unit(i)
+		if utf16.IsSurrogate(r) {
+			if i+3 < n {
+				if r = utf16.DecodeRune(r, unit(i+2)); r != utf8.RuneError {
+					i += 2
+				}
+			} else {
+				r = utf8.RuneError
+			}
+		}
+		dst = utf8.AppendRune(dst, r)
+	}
+	if n < len(src) {
+		dst = utf8.AppendRune(dst, utf8.RuneError)
+	}
+	return dst
+}
+
+// Transcode single-byte encoded src using table of the upper half of the charset.
+func decodeSingleByte(dst, src []byte, table *[128]rune) []byte {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; c < utf8.RuneSelf {
+			dst = append(dst, c)
+		} else {
+			dst = utf8.AppendRune(dst, table[c-utf8.RuneSelf])
+		}
+	}
+	return dst
+}
+
+// Upper half (0x80-0xFF) of windows-1251 charset.
+var cp1251 = [128]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0xFFFD, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7,
+	0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7,
+	0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+	0x0410, 0x0411, 0x0412, 0x0413, 0x0414, 0x0415, 0x0416, 0x0417,
+	0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E, 0x041F,
+	0x0420, 0x0421, 0x0422, 0x0423, 0x0424, 0x0425, 0x0426, 0x0427,
+	0x0428, 0x0429, 0x042A, 0x042B, 0x042C, 0x042D, 0x042E, 0x042F,
+	0x0430, 0x0431, 0x0432, 0x0433, 0x0434, 0x0435, 0x0436, 0x0437,
+	0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E, 0x043F,
+	0x0440, 0x0441, 0x0442, 0x0443, 0x0444, 0x0445, 0x0446, 0x0447,
+	0x0448, 0x0449, 0x044A, 0x044B, 0x044C, 0x044D, 0x044E, 0x044F,
+}
+
+// Upper half (0x80-0xFF) of windows-1250 charset.
+var cp1250 = [128]rune{
+	0x20AC, 0xFFFD, 0x201A, 0xFFFD, 0x201E, 0x2026, 0x2020, 0x2021,
+	0xFFFD, 0x2030, 0x0160, 0x2039, 0x015A, 0x0164, 0x017D, 0x0179,
+	0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0xFFFD, 0x2122, 0x0161, 0x203A, 0x015B, 0x0165, 0x017E, 0x017A,
+	0x00A0, 0x02C7, 0x02D8, 0x0141, 0x00A4, 0x0104, 0x00A6, 0x00A7,
+	0x00A8, 0x00A9, 0x015E, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x017B,
+	0x00B0, 0x00B1, 0x02DB, 0x0142, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+	0x00B8, 0x0105, 0x015F, 0x00BB, 0x013D, 0x02DD, 0x013E, 0x017C,
+	0x0154, 0x00C1, 0x00C2, 0x0102, 0x00C4, 0x0139, 0x0106, 0x00C7,
+	0x010C, 0x00C9, 0x0118, 0x00CB, 0x011A, 0x00CD, 0x00CE, 0x010E,
+	0x0110, 0x0143, 0x0147, 0x00D3, 0x00D4, 0x0150, 0x00D6, 0x00D7,
+	0x0158, 0x016E, 0x00DA, 0x0170, 0x00DC, 0x00DD, 0x0162, 0x00DF,
+	0x0155, 0x00E1, 0x00E2, 0x0103, 0x00E4, 0x013A, 0x0107, 0x00E7,
+	0x010D, 0x00E9, 0x0119, 0x00EB, 0x011B, 0x00ED, 0x00EE, 0x010F,
+	0x0111, 0x0144, 0x0148, 0x00F3, 0x00F4, 0x0151, 0x00F6, 0x00F7,
+	0x0159, 0x016F, 0x00FA, 0x0171, 0x00FC, 0x00FD, 0x0163, 0x02D9,
+}
+
+// Upper half (0x80-0xFF) of ISO-8859-2 charset.
+var iso88592 = [128]rune{
+	0x0080, 0x0081, 0x0082, 0x0083, 0x0084, 0x0085, 0x0086, 0x0087,
+	0x0088, 0x0089, 0x008A, 0x008B, 0x008C, 0x008D, 0x008E, 0x008F,
+	0x0090, 0x0091, 0x0092, 0x0093, 0x0094, 0x0095, 0x0096, 0x0097,
+	0x0098, 0x0099, 0x009A, 0x009B, 0x009C, 0x009D, 0x009E, 0x009F,
+	0x00A0, 0x0104, 0x02D8, 0x0141, 0x00A4, 0x013D, 0x015A, 0x00A7,
+	0x00A8, 0x0160, 0x015E, 0x0164, 0x0179, 0x00AD, 0x017D, 0x017B,
+	0x00B0, 0x0105, 0x02DB, 0x0142, 0x00B4, 0x013E, 0x015B, 0x02C7,
+	0x00B8, 0x0161, 0x015F, 0x0165, 0x017A, 0x02DD, 0x017E, 0x017C,
+	0x0154, 0x00C1, 0x00C2, 0x0102, 0x00C4, 0x0139, 0x0106, 0x00C7,
+	0x010C, 0x00C9, 0x0118, 0x00CB, 0x011A, 0x00CD, 0x00CE, 0x010E,
+	0x0110, 0x0143, 0x0147, 0x00D3, 0x00D4, 0x0150, 0x00D6, 0x00D7,
+	0x0158, 0x016E, 0x00DA, 0x0170, 0x00DC, 0x00DD, 0x0162, 0x00DF,
+	0x0155, 0x00E1, 0x00E2, 0x0103, 0x00E4, 0x013A, 0x0107, 0x00E7,
+	0x010D, 0x00E9, 0x0119, 0x00EB, 0x011B, 0x00ED, 0x00EE, 0x010F,
+	0x0111, 0x0144, 0x0148, 0x00F3, 0x00F4, 0x0151, 0x00F6, 0x00F7,
+	0x0159, 0x016F, 0x00FA, 0x0171, 0x00FC, 0x00FD, 0x0163, 0x02D9,
+}
+
+// Upper half (0x80-0xFF) of windows-1252 charset.
+var cp1252 = [128]rune{
+	0x20AC, 0xFFFD, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0xFFFD, 0x017D, 0xFFFD,
+	0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0xFFFD, 0x017E, 0x0178,
+	0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7,
+	0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF,
+	0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+	0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF,
+	0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7,
+	0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF,
+	0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7,
+	0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF,
+	0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7,
+	0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF,
+	0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7,
+	0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF,
+}
+
+// Upper half (0x80-0xFF) of KOI8-R charset.
+var koi8r = [128]rune{
+	0x2500, 0x2502, 0x250C, 0x2510, 0x2514, 0x2518, 0x251C, 0x2524,
+	0x252C, 0x2534, 0x253C, 0x2580, 0x2584, 0x2588, 0x258C, 0x2590,
+	0x2591, 0x2592, 0x2593, 0x2320, 0x25A0, 0x2219, 0x221A, 0x2248,
+	0x2264, 0x2265, 0x00A0, 0x2321, 0x00B0, 0x00B2, 0x00B7, 0x00F7,
+	0x2550, 0x2551, 0x2552, 0x0451, 0x2553, 0x2554, 0x2555, 0x2556,
+	0x2557, 0x2558, 0x2559, 0x255A, 0x255B, 0x255C, 0x255D, 0x255E,
+	0x255F, 0x2560, 0x2561, 0x0401, 0x2562, 0x2563, 0x2564, 0x2565,
+	0x2566, 0x2567, 0x2568, 0x2569, 0x256A, 0x256B, 0x256C, 0x00A9,
+	0x044E, 0x0430, 0x0431, 0x0446, 0x0434, 0x0435, 0x0444, 0x0433,
+	0x0445, 0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E,
+	0x043F, 0x044F, 0x0440, 0x0441, 0x0442, 0x0443, 0x0436, 0x0432,
+	0x044C, 0x044B, 0x0437, 0x0448, 0x044D, 0x0449, 0x0447, 0x044A,
+	0x042E, 0x0410, 0x0411, 0x0426, 0x0414, 0x0415, 0x0424, 0x0413,
+	0x0425, 0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E,
+	0x041F, 0x042F, 0x0420, 0x0421, 0x0422, 0x0423, 0x0416, 0x0412,
+	0x042C, 0x042B, 0x0417, 0x0428, 0x042D, 0x0429, 0x0427, 0x042A,
+}
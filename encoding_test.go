@@ -0,0 +1,115 @@
+package xmlvector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestEncoding(t *testing.T) {
+	vec := NewVector()
+	t.Run("encoding/cp1251", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "root.name", "Привет, мир", vector.TypeString)
+		assertStr(t, vec, "root.quote", "«Ёлка» — №1", vector.TypeString)
+		assertStr(t, vec, "@encoding", "UTF-8", vector.TypeAttribute)
+		if enc := vec.Encoding(); enc != "windows-1251" {
+			t.Error("encoding mismatch, got", enc)
+		}
+	})
+	t.Run("encoding/latin1", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "root.name", "François Müller", vector.TypeString)
+		if enc := vec.Encoding(); enc != "iso-8859-1" {
+			t.Error("encoding mismatch, got", enc)
+		}
+	})
+	t.Run("root/unicode", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		if enc := vec.Encoding(); enc != "utf-8" {
+			t.Error("encoding mismatch, got", enc)
+		}
+	})
+	for _, c := range []struct{ stage, enc string }{
+		{"encoding/utf16le", "utf-16le"},
+		{"encoding/utf16be", "utf-16be"},
+		{"encoding/utf8bom", "utf-8"},
+		{"encoding/utf16decl", "utf-8"},
+	} {
+		t.Run(c.stage, func(t *testing.T) {
+			assertParse(t, vec, nil, 0)
+			assertStr(t, vec, "root.name", "Привет 😀", vector.TypeString)
+			if enc := vec.Encoding(); enc != c.enc {
+				t.Error("encoding mismatch, got", enc)
+			}
+		})
+	}
+	for _, c := range []struct{ enc, alias, src, text string }{
+		{"iso-8859-2", "latin2",
+			"Za\xbf\xf3\xb3\xe6 g\xea\xb6l\xb1 ja\xbc\xf1, P\xf8\xedli\xb9 \xbelu\xbbou\xe8k\xfd k\xf9\xf2",
+			"Zażółć gęślą jaźń, Příliš žluťoučký kůň"},
+		{"windows-1250", "cp1250",
+			"Za\xbf\xf3\xb3\xe6 g\xea\x9cl\xb9 ja\x9f\xf1, P\xf8\xedli\x9a \x9elu\x9dou\xe8k\xfd k\xf9\xf2",
+			"Zażółć gęślą jaźń, Příliš žluťoučký kůň"},
+		{"windows-1252", "cp1252",
+			"\x8cuvre \x80 5 \x96 \xabna\xefve\xbb caf\xe9",
+			"Œuvre € 5 – «naïve» café"},
+		{"koi8-r", "koi8r",
+			"\xf3\xdf\xc5\xdb\xd8 \xd6\xc5 \xc5\xdd\xa3 \xdc\xd4\xc9\xc8 \xcd\xd1\xc7\xcb\xc9\xc8 \xc2\xd5\xcc\xcf\xcb",
+			"Съешь же ещё этих мягких булок"},
+	} {
+		t.Run(c.enc, func(t *testing.T) {
+			vec := NewVector()
+			if err := vec.ParseCopyString(`<?xml version="1.0" encoding="` + c.alias + `"?><a>` + c.src + `</a>`); err != nil {
+				t.Fatal(err)
+			}
+			assertStr(t, vec, "a", c.text, vector.TypeString)
+			if enc := vec.Encoding(); enc != c.enc {
+				t.Error("encoding mismatch, got", enc)
+			}
+			vec.Reset()
+			vec.SetEncoding(c.enc)
+			if err := vec.ParseCopyString(`<a>` + c.src + `</a>`); err != nil {
+				t.Fatal(err)
+			}
+			assertStr(t, vec, "a", c.text, vector.TypeString)
+		})
+	}
+	t.Run("override", func(t *testing.T) {
+		vec := NewVector().SetEncoding("cp1251")
+		if err := vec.Parse([]byte("<a>\xcf\xf0\xe8</a>")); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "a", "При", vector.TypeString)
+
+		RegisterDecoder(func(dst, src []byte) ([]byte, error) {
+			return nil, errors.New("custom failure")
+		}, "x-custom")
+		vec.SetEncoding("X-Custom")
+		if err := vec.Parse([]byte("<a/>")); err == nil || err.Error() != "custom failure" {
+			t.Error("error mismatch, got", err)
+		}
+		vec.SetEncoding("utf-16le")
+		if err := vec.Parse([]byte("\xfe\xff\x00<\x00a\x00/\x00>")); err != nil {
+			t.Fatal(err)
+		}
+		if enc := vec.Encoding(); enc != "utf-16be" {
+			t.Error("BOM must have priority, got", enc)
+		}
+		vec.SetEncoding("koi8-u")
+		if err := vec.Parse([]byte("<a/>")); err != ErrUnknownEncoding {
+			t.Error("error mismatch, got", err)
+		}
+	})
+}
+
+func BenchmarkEncoding(b *testing.B) {
+	b.Run("encoding/cp1251", func(b *testing.B) {
+		bench(b, func(vec *Vector) {
+			if s := vec.Dot("root.name").String(); s != "Привет, мир" {
+				b.Error("value mismatch, got", s)
+			}
+		})
+	})
+}
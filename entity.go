@@ -0,0 +1,84 @@
+package xmlvector
+
+import "github.com/koykov/byteconv"
+
+// Entities is a table of named character entities recognized by Unescape in addition to predefined XML entities.
+//
+// Entities are unescaped in place, so replacement can't be longer than the reference (including ampersand and
+// semicolon). Table isn't safe for concurrent registration and use.
+type Entities struct {
+	// Use HTML5 named character references.
+	html bool
+	// Shared table, see HTMLEntities.
+	ro bool
+	// Custom entities.
+	m map[string]string
+}
+
+// NewEntities makes new table of entities. Set html to recognize HTML5 named character references, eg: &nbsp;.
+func NewEntities(html bool) *Entities {
+	return &Entities{html: html}
+}
+
+var htmlEntities = &Entities{html: true, ro: true}
+
+// HTMLEntities returns shared read-only table of HTML5 named character references. Use NewEntities(true) to make a
+// table with custom entities.
+func HTMLEntities() *Entities {
+	return htmlEntities
+}
+
+// Register adds custom entity with given name (without ampersand and semicolon), eg: Register("copy", "©").
+//
+// Custom entities have priority over HTML5 references. Reference including ampersand and semicolon can't be longer than
+// maxRefLen, so longer names are rejected with ErrEntityName. The shared table returned by HTMLEntities is read-only,
+// registration in it fails with ErrReadOnly.
+func (e *Entities) Register(name, value string) error {
+	if e.ro {
+		return ErrReadOnly
+	}
+	if len(name) == 0 || len(name) > maxRefLen-2 {
+		return ErrEntityName
+	}
+	if len(value) > len(name)+2 {
+		return ErrLongEntity
+	}
+	if e.m == nil {
+		e.m = make(map[string]string)
+	}
+	e.m[name] = value
+	return nil
+}
+
+// Lookup returns replacement of the entity with given name.
+func (e *Entities) Lookup(name []byte) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	if v, ok := e.m[byteconv.B2S(name)]; ok {
+		return v, true
+	}
+	if e.html {
+		v, ok := html5Entities[byteconv.B2S(name)]
+		return v, ok
+	}
+	return "", false
+}
+
+// SetEntities sets table of named entities used for unescaping values in addition to predefined XML entities.
+//
+// Nil table (default) means strict XML.
+func (vec *Vector) SetEntities(ent *Entities) *Vector {
+	vec.Helper = Helper{ent: ent}
+	return vec
+}
+
+// SetStrictRefs enables strict mode of references checking. In strict mode malformed character references (eg: "&#;",
+// surrogates or code points out of XML Char range) and references to unknown entities fail parsing with ErrBadRef or
+// ErrUnknownEntity errors. Error offset points to the ampersand of the reference.
+//
+// By default such references keep in values as is.
+func (vec *Vector) SetStrictRefs(strict bool) *Vector {
+	vec.strictRefs = strict
+	return vec
+}
@@ -3,8 +3,26 @@ package xmlvector
 import "errors"
 
 var (
-	ErrBadAttr     = errors.New("bad attribute")
-	ErrNoRoot      = errors.New("no root tag")
-	ErrUnclosedTag = errors.New("unclosed tag")
-	ErrUnexpToken  = errors.New("unexpected token")
+	ErrBadAttr         = errors.New("bad attribute")
+	ErrBadName         = errors.New("bad element or attribute name")
+	ErrBadComment      = errors.New("comment contains \"--\" or ends with \"-\"")
+	ErrNoRoot          = errors.New("no root tag")
+	ErrUnclosedTag     = errors.New("unclosed tag")
+	ErrUnexpToken      = errors.New("unexpected token")
+	ErrNoOpenElem      = errors.New("no open element")
+	ErrMixedContent    = errors.New("mixed content isn't supported")
+	ErrMultiRoot       = errors.New("multiple root elements")
+	ErrAttrOrder       = errors.New("attribute after element content")
+	ErrTagMismatch     = errors.New("closing tag doesn't match open element")
+	ErrUnknownEncoding = errors.New("unknown encoding")
+	ErrLongEntity      = errors.New("entity replacement is longer than reference")
+	ErrEntityName      = errors.New("entity name is empty or too long")
+	ErrReadOnly        = errors.New("entities table is read-only")
+	ErrBadRef          = errors.New("malformed reference")
+	ErrUnknownEntity   = errors.New("unknown entity")
+	ErrMaxDepth        = errors.New("nesting depth limit exceeded")
+	ErrMaxAttrs        = errors.New("attributes count limit exceeded")
+	ErrLongName        = errors.New("name length limit exceeded")
+	ErrMaxNodes        = errors.New("nodes count limit exceeded")
+	ErrLongText        = errors.New("text length limit exceeded")
 )
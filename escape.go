@@ -0,0 +1,36 @@
+package xmlvector
+
+import "io"
+
+var escTable = [256][]byte{
+	'<': beLt,
+	'>': beGt,
+	'&': beAmp,
+	'"': beQuot,
+}
+
+// Escape appends p to dst replacing special symbols with XML entities.
+func Escape(dst, p []byte) []byte {
+	var off int
+	for i := 0; i < len(p); i++ {
+		if e := escTable[p[i]]; e != nil {
+			dst = append(dst, p[off:i]...)
+			dst = append(dst, e...)
+			off = i + 1
+		}
+	}
+	return append(dst, p[off:]...)
+}
+
+// Write escaped p to w.
+func writeEscape(w io.Writer, p []byte) {
+	var off int
+	for i := 0; i < len(p); i++ {
+		if e := escTable[p[i]]; e != nil {
+			_, _ = w.Write(p[off:i])
+			_, _ = w.Write(e)
+			off = i + 1
+		}
+	}
+	_, _ = w.Write(p[off:])
+}
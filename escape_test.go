@@ -0,0 +1,20 @@
+package xmlvector
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	stages := map[string]string{
+		"ten < twenty":         "ten &lt; twenty",
+		`"quoted" & <tagged/>`: "&quot;quoted&quot; &amp; &lt;tagged/&gt;",
+		"I'd like to go":       "I'd like to go",
+		"company_name © brand": "company_name © brand",
+	}
+	for origin, expect := range stages {
+		if s := string(Escape(nil, []byte(origin))); s != expect {
+			t.Errorf("escape mismatch, need %s got %s", expect, s)
+		}
+		if s := string(Unescape(Escape(nil, []byte(origin)))); s != origin {
+			t.Errorf("escape/unescape mismatch, need %s got %s", origin, s)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package xmlvector
+
+import (
+	"bytes"
+	"iter"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+var bPIStart = []byte("<?")
+
+// ParseFragment parses source bytes as a fragment: zero or more top-level elements and text without prolog, eg:
+// concatenated log events or HTML snippet.
+//
+// Root node contains top-level elements (see Children) and text nodes. Whitespace-only text, comments and processing
+// instructions among top-level elements are skipped.
+func (vec *Vector) ParseFragment(s []byte) error {
+	return vec.parse(s, false, true)
+}
+
+// ParseFragmentString parses source string as a fragment, see ParseFragment.
+func (vec *Vector) ParseFragmentString(s string) error {
+	return vec.parse(byteconv.S2B(s), false, true)
+}
+
+// ParseFragmentCopy copies source bytes and parse it as a fragment, see ParseFragment.
+func (vec *Vector) ParseFragmentCopy(s []byte) error {
+	return vec.parse(s, true, true)
+}
+
+// SetMultiDoc enables multi-document mode: source may contain concatenated documents (each with optional prolog and
+// doctype), every document becomes a separate root node. Use Documents to iterate them, Root and Dot methods work with
+// the first document.
+func (vec *Vector) SetMultiDoc(value bool) *Vector {
+	vec.multiDoc = value
+	return vec
+}
+
+// Documents returns an iterator over root nodes of parsed documents (see SetMultiDoc).
+func (vec *Vector) Documents() iter.Seq2[int, *vector.Node] {
+	return func(yield func(int, *vector.Node) bool) {
+		for i := 0; i < vec.RootLen(); i++ {
+			if !yield(i, vec.RootByIndex(i)) {
+				return
+			}
+		}
+	}
+}
+
+// IsText checks if the node is a text node of the fragment.
+func IsText(node *vector.Node) bool {
+	return node.Key().CheckBit(flagText)
+}
+
+// Parse the rest of documents after the first one.
+func (vec *Vector) parseDocs(offset int) (int, error) {
+	var eof bool
+	src := vec.Src()
+	n := len(src)
+	for offset < n {
+		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
+			break
+		}
+		_, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+		vec.setPos(i, offset, n)
+		start := offset
+		var err error
+		if offset, err = vec.parseGeneric(0, offset, i); err != nil {
+			return offset, err
+		}
+		vec.setPos(i, start, offset)
+	}
+	return offset, nil
+}
+
+// Parse top-level elements and text of the fragment. Root node is addressed by index ri, see parseGeneric.
+func (vec *Vector) parseFragment(offset, ri int) (int, error) {
+	var (
+		err error
+		eof bool
+		cn  *vector.Node
+	)
+	src := vec.Src()
+	n := len(src)
+	_ = src[n-1]
+	depth := 1
+	root := vec.NodeAt(ri)
+	root.Key().SetBit(flagFragment, true)
+	root.SetOffset(vec.Index.Len(depth))
+	for offset < n {
+		p := offset
+		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
+			break
+		}
+		switch {
+		case src[offset] != '<':
+			if vector.IndexByteAt(src[:offset], '<', p) == -1 {
+				// Text keeps leading whitespaces (unless comment was skipped).
+				offset = p
+			}
+			fallthrough
+		case bytes.HasPrefix(src[offset:], bCDATAOpen):
+			if offset, err = vec.parseText(depth, offset, ri); err != nil {
+				return offset, err
+			}
+		case bytes.HasPrefix(src[offset:], bPIStart):
+			if p = bytealg.IndexAtBytes(src, bPIClose, offset); p == -1 {
+				return offset, vector.ErrUnexpEOF
+			}
+			offset = p + len(bPIClose)
+		default:
+			if vec.recover {
+				if offset = vec.skipStray(src, n, offset); offset == n {
+					continue
+				}
+			}
+			if cn, _, offset, err = vec.parseElement(depth, offset, ri); err != nil {
+				if !vec.recover || isFatal(err) {
+					return offset, err
+				}
+				vec.diag(offset, err)
+			}
+			if cn != nil {
+				vec.closeNode(cn)
+			}
+			// Element parser skips trailing whitespaces, but they may belong to the following text.
+			for offset > p && skipTable[src[offset-1]] {
+				offset--
+			}
+			if err != nil {
+				if offset, eof = vec.resync(src, n, offset); eof {
+					return offset, nil
+				}
+				if bytes.HasPrefix(src[offset:], bCTag) {
+					offset = vec.skipStray(src, n, offset)
+				}
+			}
+		}
+	}
+	vec.closeNode(vec.NodeAt(ri))
+	return offset, nil
+}
+
+// Parse top-level text (or CDATA section) of the fragment up to the next tag.
+func (vec *Vector) parseText(depth, offset, ri int) (int, error) {
+	src := vec.Src()
+	n := len(src)
+	var (
+		p, d  int
+		cdata bool
+	)
+	if offset, cdata = skipCDATA(src, n, offset); cdata {
+		if p = bytealg.IndexAtBytes(src, bCDATAClose, offset); p == -1 {
+			return offset, vector.ErrUnexpEOF
+		}
+		d = len(bCDATAClose)
+	} else if p = vector.IndexByteAt(src, '<', offset); p == -1 {
+		p = n
+	}
+	raw := src[offset:p]
+	if !cdata && len(bytealg.TrimBytesFmt4(raw)) == 0 {
+		return p, nil
+	}
+	if l := vec.limits.MaxTextLen; l > 0 && p-offset > l {
+		return offset, ErrLongText
+	}
+	esc := !cdata && vec.checkEscape(raw)
+	if esc && vec.strictRefs {
+		if off, err := vec.checkRefs(raw); err != nil {
+			if !vec.recover {
+				return offset + off, err
+			}
+			vec.diag(offset+off, err)
+		}
+	}
+	node, i, err := vec.acquireChild(ri, depth, vector.TypeString)
+	if err != nil {
+		return offset, err
+	}
+	node.Key().SetBit(flagText, true)
+	node.Value().InitRaw(vec.SrcAddr(), offset, p-offset)
+	node.Value().SetBit(flagEscape, esc)
+	node.Value().SetBit(flagCDATA, cdata)
+	node.Value().SetBit(flagNormEOL, bytes.IndexByte(raw, '\r') != -1)
+	vec.closeNode(node)
+	vec.setPos(i, offset, p+d)
+	return p + d, nil
+}
+
+// Make empty fragment.
+func (vec *Vector) emptyFragment() error {
+	if err := vec.SetSrc(bPairs, false); err != nil {
+		return err
+	}
+	root, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	root.Key().SetBit(flagFragment, true)
+	vec.closeNode(root)
+	vec.ReleaseNode(i, root)
+	return nil
+}
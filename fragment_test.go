@@ -0,0 +1,97 @@
+package xmlvector
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestFragment(t *testing.T) {
+	vec := NewVector()
+	t.Run("fragment/events", func(t *testing.T) {
+		st := getStage(getTBName(t))
+		vec.Reset()
+		if err := vec.ParseFragmentCopy(st.origin); err != nil {
+			t.Fatal(err)
+		}
+		var events []string
+		for _, event := range Group(vec.Root(), "event") {
+			events = append(events, event.Dot("@id").String()+":"+event.String())
+		}
+		if len(events) != 2 || events[0] != "1:start" || events[1] != "2:stop" {
+			t.Error("events mismatch, got", events)
+		}
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		if buf.String() != `<event id="1">start</event><event id="2">stop</event>` {
+			t.Error("marshal mismatch, got", buf.String())
+		}
+	})
+	t.Run("fragment/mixed", func(t *testing.T) {
+		st := getStage(getTBName(t))
+		vec.Reset()
+		if err := vec.ParseFragmentCopy(st.origin); err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		vec.Root().Each(func(_ int, node *vector.Node) {
+			if IsText(node) {
+				texts = append(texts, node.String())
+			}
+		})
+		expect := []string{"Hello, ", "! ", "Bye & ", " you", " <soon> "}
+		if len(texts) != len(expect) {
+			t.Fatalf("texts mismatch, need %q got %q", expect, texts)
+		}
+		for i := range expect {
+			if texts[i] != expect[i] {
+				t.Errorf("text #%d mismatch, need %q got %q", i, expect[i], texts[i])
+			}
+		}
+		assertStr(t, vec, "b", "world", vector.TypeString)
+		assertStr(t, vec, "i", "see", vector.TypeString)
+
+		texts = texts[:0]
+		for node := range Texts(vec.Root()) {
+			texts = append(texts, node.String())
+		}
+		if s := strings.Join(texts, "|"); s != "Hello, |world|! |Bye & |see| you| <soon> " {
+			t.Errorf("texts mismatch, got %q", s)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseFragmentString(" \n"); err != nil {
+			t.Fatal(err)
+		}
+		for range Children(vec.Root()) {
+			t.Error("unexpected child")
+		}
+	})
+	t.Run("fragment/multi", func(t *testing.T) {
+		st := getStage(getTBName(t))
+		vec.Reset()
+		if err := vec.ParseCopy(st.origin); err != vector.ErrUnparsedTail {
+			t.Error("error mismatch, got", err)
+		}
+		vec.SetMultiDoc(true).Reset()
+		defer vec.SetMultiDoc(false)
+		if err := vec.ParseCopy(st.origin); err != nil {
+			t.Fatal(err)
+		}
+		var ids, versions []string
+		for _, doc := range vec.Documents() {
+			ids = append(ids, doc.Dot("event@id").String())
+			versions = append(versions, doc.Dot("@version").String())
+		}
+		if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "3" {
+			t.Error("ids mismatch, got", ids)
+		}
+		if len(versions) != 3 || versions[0] != "1.0" || versions[1] != "1.1" || versions[2] != "1.0" {
+			t.Error("versions mismatch, got", versions)
+		}
+		assertStr(t, vec, "event@id", "1", vector.TypeAttribute)
+	})
+}
@@ -1,6 +1,6 @@
 module github.com/koykov/xmlvector
 
-go 1.18
+go 1.23
 
 require (
 	github.com/koykov/bytealg v1.0.7
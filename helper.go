@@ -6,15 +6,34 @@ import (
 	"github.com/koykov/vector"
 )
 
-type Helper struct{}
+// Helper implements vector.Helper interface.
+type Helper struct {
+	// Named entities table, see SetEntities.
+	ent *Entities
+}
 
 var helper = Helper{}
 
 func (h Helper) Indirect(p *vector.Byteptr) []byte {
 	b := p.RawBytes()
+	if p.CheckBit(flagNormEOL) {
+		p.SetBit(flagNormEOL, false)
+		b = normalizeEOL(b)
+		p.SetLen(len(b))
+	}
+	if p.CheckBit(flagNormAttr) {
+		p.SetBit(flagNormAttr, false)
+		b = normalizeAttr(b)
+		p.SetLen(len(b))
+	}
 	if p.CheckBit(flagEscape) {
 		p.SetBit(flagEscape, false)
-		b = Unescape(b)
+		b = UnescapeEntities(b, h.ent)
+		p.SetLen(len(b))
+	}
+	if p.CheckBit(flagNormTok) {
+		p.SetBit(flagNormTok, false)
+		b = normalizeTok(b)
 		p.SetLen(len(b))
 	}
 	return b
@@ -0,0 +1,231 @@
+package xmlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// SetHTML enables HTML mode for almost-XML input:
+//   - element and attribute names are case-insensitive and are converted to lower case (source of the caller is never
+//     modified, it's copied to the vector like in ParseCopy);
+//   - void elements (br, img, input, ...) have no content and closing tags;
+//   - content of raw text elements (script, style, textarea, title) is taken as is up to the closing tag;
+//   - end tags of p, li, td, option, ... may be omitted and are implied by the following start tag or by the closing
+//     tag of the parent;
+//   - lenient attributes (see SetLenientAttrs) are accepted.
+//
+// Mixed content isn't supported by the node tree, so text among child elements is skipped. HTML named entities may be
+// enabled using SetEntities(HTMLEntities()).
+func (vec *Vector) SetHTML(html bool) *Vector {
+	vec.html = html
+	return vec
+}
+
+// Max length of known HTML element name.
+const maxHTMLName = 16
+
+// Get name of the tag starting at offset (at '<') converted to lower case into buf.
+func htmlTagName(buf []byte, src []byte, n, offset int) []byte {
+	offset++
+	p := skipAttrName(src, n, offset)
+	if p-offset > len(buf) {
+		return nil
+	}
+	name := buf[:p-offset]
+	copy(name, src[offset:p])
+	toLower(name)
+	return name
+}
+
+// Convert ASCII letters of p to lower case in place. Must be applied only to the memory owned by the vector.
+func toLower(p []byte) {
+	for i := 0; i < len(p); i++ {
+		if c := p[i]; c >= 'A' && c <= 'Z' {
+			p[i] = c + 'a' - 'A'
+		}
+	}
+}
+
+// Check if element has no content and closing tag.
+func isVoidElem(name []byte) bool {
+	switch byteconv.B2S(name) {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track",
+		"wbr":
+		return true
+	}
+	return false
+}
+
+// Check if element contains raw text. Escapable raw text (textarea, title) may contain references.
+func isRawTextElem(name []byte) (raw, esc bool) {
+	switch byteconv.B2S(name) {
+	case "script", "style":
+		return true, false
+	case "textarea", "title":
+		return true, true
+	}
+	return false, false
+}
+
+// Check if end tag of the element may be omitted.
+func hasOptionalEnd(name []byte) bool {
+	switch byteconv.B2S(name) {
+	case "p", "li", "dt", "dd", "option", "optgroup", "tr", "td", "th", "thead", "tbody", "tfoot", "colgroup",
+		"rt", "rp", "head", "body", "html":
+		return true
+	}
+	return false
+}
+
+// Check if start tag of the element next implies end tag of the open element.
+func impliesEnd(open, next []byte) bool {
+	o, x := byteconv.B2S(open), byteconv.B2S(next)
+	switch o {
+	case "p":
+		switch x {
+		case "address", "article", "aside", "blockquote", "details", "div", "dl", "fieldset", "figcaption", "figure",
+			"footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "hr", "main", "menu", "nav",
+			"ol", "p", "pre", "section", "table", "ul":
+			return true
+		}
+	case "li":
+		return x == "li"
+	case "dt", "dd":
+		return x == "dt" || x == "dd"
+	case "option":
+		return x == "option" || x == "optgroup"
+	case "optgroup":
+		return x == "optgroup"
+	case "tr":
+		return x == "tr" || x == "tbody" || x == "tfoot"
+	case "td", "th":
+		return x == "td" || x == "th" || x == "tr" || x == "tbody" || x == "tfoot"
+	case "thead", "tbody":
+		return x == "tbody" || x == "tfoot"
+	case "rt", "rp":
+		return x == "rt" || x == "rp"
+	case "head":
+		return x == "body"
+	}
+	return false
+}
+
+// Check if start tag at offset implies end tag of the node.
+func (vec *Vector) impliedEnd(node *vector.Node, src []byte, n, offset int) bool {
+	if offset+1 >= n || src[offset+1] == '/' || src[offset+1] == '!' {
+		return false
+	}
+	var buf [maxHTMLName]byte
+	return impliesEnd(node.Key().RawBytes(), htmlTagName(buf[:], src, n, offset))
+}
+
+// Skip text before the next tag.
+func skipHTMLText(src []byte, n, offset int) (int, bool) {
+	if offset < n && src[offset] == '<' {
+		return offset, false
+	}
+	if p := vector.IndexByteAt(src, '<', offset); p != -1 {
+		return p, false
+	}
+	return n, true
+}
+
+// Parse content of void and raw text elements.
+//
+// Returns true if content was parsed, otherwise content must be parsed as usual.
+func (vec *Vector) parseHTMLContent(offset int, node *vector.Node, tag []byte) (int, bool, error) {
+	if isVoidElem(tag) {
+		return offset, true, nil
+	}
+	raw, esc := isRawTextElem(tag)
+	if !raw {
+		return offset, false, nil
+	}
+	src := vec.Src()
+	n := len(src)
+	p := offset
+	for {
+		if p = bytealg.IndexAtBytes(src, bCTag, p); p == -1 {
+			return n, true, vector.ErrUnexpEOF
+		}
+		if p+2+len(tag) <= n && bytes.EqualFold(src[p+2:p+2+len(tag)], tag) {
+			break
+		}
+		p += 2
+	}
+	if l := vec.limits.MaxTextLen; l > 0 && p-offset > l {
+		return offset, true, ErrLongText
+	}
+	text := src[offset:p]
+	node.Value().InitRaw(vec.SrcAddr(), offset, p-offset)
+	node.Value().SetBit(flagEscape, esc && vec.checkEscape(text))
+	node.Value().SetBit(flagNormEOL, bytes.IndexByte(text, '\r') != -1)
+	if !node.Key().CheckBit(flagAttr) {
+		node.SetType(vector.TypeString)
+	}
+	offset, err := vec.skipCTag(src, n, p, tag)
+	return offset, true, err
+}
+
+// Skip close tag of the element. In HTML mode the name is checked and omitted end tags are implied (see
+// hasOptionalEnd).
+func (vec *Vector) skipCTag(src []byte, n, offset int, tag []byte) (int, error) {
+	if vec.recover {
+		return vec.recoverCTag(src, n, offset, tag)
+	}
+	if !vec.html {
+		return skipCTag(src, n, offset, tag)
+	}
+	if p := matchCTag(src, n, offset, tag); p != -1 {
+		return p, nil
+	}
+	if hasOptionalEnd(tag) {
+		return offset, nil
+	}
+	return offset, ErrUnclosedTag
+}
+
+// Check if close tag of the element starts at offset (case-insensitive) and return offset after it or -1.
+func matchCTag(src []byte, n, offset int, tag []byte) int {
+	p := offset + 2 + len(tag)
+	if p >= n || !bytes.HasPrefix(src[offset:], bCTag) || !bytes.EqualFold(src[offset+2:p], tag) {
+		return -1
+	}
+	for p < n && skipTable[src[p]] {
+		p++
+	}
+	if p < n && src[p] == '>' {
+		return p + 1
+	}
+	return -1
+}
+
+// Trim trailing whitespaces of text if the element's end tag is implied, since they belong to the markup.
+func (vec *Vector) trimImplied(node *vector.Node, src []byte, n, offset, p int) int {
+	if matchCTag(src, n, p, node.Key().RawBytes()) != -1 {
+		return p
+	}
+	for p > offset && skipTable[src[p-1]] {
+		p--
+	}
+	return p
+}
+
+// Check if source is stored in the memory owned by the vector (read buffer or mapped file), so it may be modified.
+func (vec *Vector) ownSrc(s []byte) bool {
+	if len(s) == 0 {
+		return false
+	}
+	if b := vec.Buf(); len(b) > 0 && &b[0] == &s[0] {
+		return true
+	}
+	for i := 0; i < len(vec.mmaps); i++ {
+		if &vec.mmaps[i][0] == &s[0] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package xmlvector
+
+import (
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestHTML(t *testing.T) {
+	vec := NewVector()
+	t.Run("html/page", func(t *testing.T) {
+		vec.SetHTML(true)
+		defer vec.SetHTML(false)
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "html@lang", "en", vector.TypeAttribute)
+		assertStr(t, vec, "html.head.meta@charset", "utf-8", vector.TypeAttribute)
+		assertStr(t, vec, "html.head.title", "Tom & Jerry", vector.TypeString)
+		assertStr(t, vec, "html.head.link@href", "/css/main.css", vector.TypeAttribute)
+		assertStr(t, vec, "html.head.style", "p > a { color: red; }", vector.TypeString)
+		assertStr(t, vec, "html.body.div.ul.0.a", "Home", vector.TypeObject)
+		assertStr(t, vec, "html.body.div.ul.1.a@href", "/about", vector.TypeAttribute)
+		assertStr(t, vec, "html.body.input@checked", "", vector.TypeAttribute)
+		assertStr(t, vec, "html.body.script", `if (a < b && c) { document.write("</p>"); }`, vector.TypeString)
+
+		var texts []string
+		for _, p := range Group(vec.Dot("html.body"), "p") {
+			texts = append(texts, p.String())
+		}
+		if len(texts) != 2 || texts[0] != "First" {
+			t.Error("p mismatch, got", texts)
+		}
+		var cells []string
+		for _, tr := range Children(vec.Dot("html.body.table")) {
+			for _, td := range Children(tr) {
+				cells = append(cells, td.String())
+			}
+		}
+		if len(cells) != 4 || cells[0] != "1" || cells[3] != "4" {
+			t.Error("cells mismatch, got", cells)
+		}
+	})
+	t.Run("xml", func(t *testing.T) {
+		// The same document isn't a valid XML.
+		vec.Reset()
+		if err := vec.ParseCopy(getStage("html/page").origin); err == nil {
+			t.Error("error expected")
+		}
+	})
+	t.Run("source", func(t *testing.T) {
+		vec.SetHTML(true).Reset()
+		defer vec.SetHTML(false)
+		// String literal is placed in read-only memory, so the source must not be modified.
+		if err := vec.ParseString("<HTML><BODY><P>x</BODY></HTML>"); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "html.body.p", "x", vector.TypeString)
+
+		src := []byte(`<DIV ID="a"><BR></DIV>`)
+		vec.Reset()
+		if err := vec.Parse(src); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "div@id", "a", vector.TypeAttribute)
+		if string(src) != `<DIV ID="a"><BR></DIV>` {
+			t.Error("source was modified:", string(src))
+		}
+	})
+	t.Run("unclosed", func(t *testing.T) {
+		vec.SetHTML(true).Reset()
+		defer vec.SetHTML(false)
+		if err := vec.ParseCopyString(`<div><span>x</div>`); err != ErrUnclosedTag {
+			t.Error("error mismatch, got", err)
+		}
+	})
+}
+
+func BenchmarkHTML(b *testing.B) {
+	b.Run("html/page", func(b *testing.B) {
+		vec := NewVector().SetHTML(true)
+		st := getStage(getTBName(b))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			vec.Reset()
+			_ = vec.ParseCopy(st.origin)
+			assertStr(b, vec, "html.head.title", "Tom & Jerry", vector.TypeString)
+		}
+	})
+}
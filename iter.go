@@ -0,0 +1,148 @@
+package xmlvector
+
+import (
+	"iter"
+	"unsafe"
+
+	"github.com/koykov/vector"
+)
+
+// Children returns an iterator over child elements of the node. Attributes are skipped.
+//
+// Index is a position of the element among element children of the node.
+func Children(node *vector.Node) iter.Seq2[int, *vector.Node] {
+	return func(yield func(int, *vector.Node) bool) {
+		eachChild(node, childElem, yield)
+	}
+}
+
+// Attrs returns an iterator over attributes of the node.
+func Attrs(node *vector.Node) iter.Seq2[int, *vector.Node] {
+	return func(yield func(int, *vector.Node) bool) {
+		eachChild(node, childAttr, yield)
+	}
+}
+
+// Texts returns an iterator over elements of the subtree containing character data (text or CDATA section) and text
+// nodes of fragments (see ParseFragment).
+//
+// The node itself is checked first, the rest of nodes visits in document order.
+func Texts(node *vector.Node) iter.Seq[*vector.Node] {
+	return func(yield func(*vector.Node) bool) {
+		if isText(node) && !yield(node) {
+			return
+		}
+		eachDescendant(node, childElem|childText, func(node *vector.Node) bool {
+			return !isText(node) || yield(node)
+		})
+	}
+}
+
+// Descendants returns depth-first (pre-order) iterator over all elements of the subtree except the node itself.
+func Descendants(node *vector.Node) iter.Seq[*vector.Node] {
+	return func(yield func(*vector.Node) bool) {
+		eachDescendant(node, childElem, yield)
+	}
+}
+
+// Kinds of children of the node.
+const (
+	childElem = 1 << iota
+	childAttr
+	childComment
+	childText
+)
+
+// Get kind of the child node.
+func childKind(node *vector.Node) int {
+	switch {
+	case node.Type() == vector.TypeAttribute:
+		return childAttr
+	case node.Key().CheckBit(flagComment):
+		return childComment
+	case node.Key().CheckBit(flagText):
+		return childText
+	}
+	return childElem
+}
+
+// Apply fn to each child of the node matching kinds mask until fn returns false.
+//
+// Returns false if iteration was stopped.
+func eachChild(node *vector.Node, mask int, fn func(int, *vector.Node) bool) bool {
+	c := newChildCursor(node)
+	for i := 0; ; i++ {
+		child := c.next(mask)
+		if child == nil {
+			return true
+		}
+		if !fn(i, child) {
+			return false
+		}
+	}
+}
+
+// Size of the node in the nodes array of the vector.
+const nodeSize = int(unsafe.Sizeof(vector.Node{}))
+
+// Cursor over children of the node. Keeps no state in the node, so nested cursors may be used as explicit stack
+// instead of recursion.
+type childCursor struct {
+	ci    []int
+	first *vector.Node
+	pos   int
+}
+
+// Make cursor over children of the node.
+func newChildCursor(node *vector.Node) childCursor {
+	ci := node.ChildrenIndices()
+	if len(ci) == 0 {
+		return childCursor{}
+	}
+	return childCursor{ci: ci, first: node.FirstChild()}
+}
+
+// Get the next child matching kinds mask. Returns nil if no children left.
+func (c *childCursor) next(mask int) *vector.Node {
+	for c.pos < len(c.ci) {
+		// Nodes of the vector are stored in single array, so the child is addressed relative to the first one.
+		// Indices may be unordered after mutations, thus vector.Node.Children can't be used.
+		off := (c.ci[c.pos] - c.ci[0]) * nodeSize
+		child := (*vector.Node)(unsafe.Add(unsafe.Pointer(c.first), off))
+		c.pos++
+		if childKind(child)&mask != 0 {
+			return child
+		}
+	}
+	return nil
+}
+
+// Apply fn to each child of the subtree matching kinds mask in pre-order until fn returns false.
+//
+// Uses explicit stack of cursors, so depth of the tree is limited by memory only.
+func eachDescendant(node *vector.Node, mask int, fn func(*vector.Node) bool) bool {
+	stack := []childCursor{newChildCursor(node)}
+	for len(stack) > 0 {
+		child := stack[len(stack)-1].next(mask)
+		if child == nil {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if !fn(child) {
+			return false
+		}
+		stack = append(stack, newChildCursor(child))
+	}
+	return true
+}
+
+// Check if element node contains character data.
+func isText(node *vector.Node) bool {
+	switch node.Type() {
+	case vector.TypeString:
+		return true
+	case vector.TypeObject:
+		return node.Value().Len() > 0
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package xmlvector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIter(t *testing.T) {
+	vec := NewVector()
+	t.Run("root/array", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		var c int
+		for i, node := range Children(vec.Dot("CATALOG")) {
+			if i != c || node.KeyString() != "CD" {
+				t.Errorf("unexpected child %d %s", i, node.KeyString())
+			}
+			c++
+		}
+		if c != 26 {
+			t.Errorf("children count mismatch, need 26 got %d", c)
+		}
+		c = 0
+		for i := range Children(vec.Dot("CATALOG")) {
+			if i == 2 {
+				break
+			}
+			c++
+		}
+		if c != 2 {
+			t.Errorf("break ignored, got %d iterations", c)
+		}
+	})
+	t.Run("root/attr", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		var keys []string
+		for _, attr := range Attrs(vec.Dot("root")) {
+			keys = append(keys, attr.KeyString()+"="+attr.String())
+		}
+		if s := strings.Join(keys, " "); s != "title=Foo descr=Bar arg0=qwe arg1=15" {
+			t.Error("attributes mismatch, got", s)
+		}
+		for range Children(vec.Dot("root")) {
+			t.Error("collapsed node must have no children")
+		}
+	})
+	t.Run("root/object", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		var keys []string
+		for node := range Descendants(vec.Root()) {
+			keys = append(keys, node.KeyString())
+		}
+		if s := strings.Join(keys, " "); s != "note to from heading body" {
+			t.Error("descendants mismatch, got", s)
+		}
+		keys = keys[:0]
+		for node := range Texts(vec.Dot("note")) {
+			keys = append(keys, node.String())
+			if len(keys) == 2 {
+				break
+			}
+		}
+		if s := strings.Join(keys, "|"); s != "Tove|Jani" {
+			t.Error("texts mismatch, got", s)
+		}
+	})
+	t.Run("mutated", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseString(`<r><a/><b/><c/></r>`); err != nil {
+			t.Fatal(err)
+		}
+		// Inserted node is placed to the end of nodes array, but must be visited in document order.
+		vec.Node(vec.Dot("r")).InsertChild(1, "x")
+		var keys []string
+		for _, node := range Children(vec.Dot("r")) {
+			if keys = append(keys, node.KeyString()); len(keys) == 3 {
+				break
+			}
+		}
+		if s := strings.Join(keys, " "); s != "a x b" {
+			t.Error("children mismatch, got", s)
+		}
+	})
+}
+
+func BenchmarkIter(b *testing.B) {
+	b.Run("root/array", func(b *testing.B) {
+		bench(b, func(vec *Vector) {
+			var c int
+			for node := range Descendants(vec.Root()) {
+				_ = node
+				c++
+			}
+			if c != 183 {
+				b.Error("descendants count mismatch, got", c)
+			}
+		})
+	})
+}
@@ -0,0 +1,220 @@
+package xmlvector
+
+import (
+	"io"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// JSONConvention describes mapping of XML elements to JSON and back.
+//
+// Repeated elements and elements of arrays (see SetForceArray) become JSON arrays. Element without attributes and
+// children becomes a string (text) or null (empty element), unless TextObject is set. Comments and prolog are skipped.
+//
+// On JSON to XML conversion keys starting with AttrPrefix become attributes, TextKey becomes element's text and other
+// keys become child elements (repeated for arrays). Conventions without AttrPrefix and with TextObject (eg: GData)
+// treat keys with scalar values as attributes.
+type JSONConvention struct {
+	// Prefix of attribute keys, eg: "@".
+	AttrPrefix string
+	// Key of element's text in objects, eg: "#text".
+	TextKey string
+	// Skip attributes.
+	NoAttrs bool
+	// Skip root element's name, so the root element's value becomes the JSON document.
+	NoRoot bool
+	// Always write text into object using TextKey, empty element becomes an empty object.
+	TextObject bool
+	// Name of the root element for NoRoot conventions on JSON to XML conversion, "root" if empty.
+	Root string
+}
+
+var (
+	// ConvDefault is a common convention: {"a":{"@id":"1","#text":"foo"},"b":"bar"}.
+	ConvDefault = &JSONConvention{AttrPrefix: "@", TextKey: "#text"}
+	// ConvParker is a Parker convention: attributes and root element's name are dropped.
+	ConvParker = &JSONConvention{TextKey: "#text", NoAttrs: true, NoRoot: true}
+	// ConvBadgerFish is a BadgerFish convention: {"a":{"@id":"1","$":"foo"},"b":{"$":"bar"}}.
+	ConvBadgerFish = &JSONConvention{AttrPrefix: "@", TextKey: "$", TextObject: true}
+	// ConvGData is a GData convention: {"a":{"id":"1","$t":"foo"},"b":{"$t":"bar"}}.
+	ConvGData = &JSONConvention{TextKey: "$t", TextObject: true}
+)
+
+var (
+	bjNull   = []byte("null")
+	bjObjO   = []byte("{")
+	bjObjC   = []byte("}")
+	bjArrO   = []byte("[")
+	bjArrC   = []byte("]")
+	bjColon  = []byte(":")
+	bjComma  = []byte(",")
+	bjQuote  = []byte(`"`)
+	bjEscHex = []byte("0123456789abcdef")
+)
+
+// JSON escape sequences of special symbols.
+var jsonEscTable = func() (t [256][]byte) {
+	for i := 0; i < 0x20; i++ {
+		t[i] = []byte{'\\', 'u', '0', '0', bjEscHex[i>>4], bjEscHex[i&0xf]}
+	}
+	t['\b'], t['\f'], t['\n'], t['\r'], t['\t'] = []byte(`\b`), []byte(`\f`), []byte(`\n`), []byte(`\r`), []byte(`\t`)
+	t['"'], t['\\'] = []byte(`\"`), []byte(`\\`)
+	return
+}()
+
+// WriteJSON writes the document as JSON to w using given convention (ConvDefault if nil).
+func (vec *Vector) WriteJSON(w io.Writer, conv *JSONConvention) error {
+	if conv == nil {
+		conv = ConvDefault
+	}
+	for _, node := range Children(vec.Root()) {
+		return WriteJSON(w, node, conv)
+	}
+	_, err := w.Write(bjNull)
+	return err
+}
+
+// WriteJSON writes the element node as JSON to w using given convention (ConvDefault if nil).
+//
+// Element is written as an object with single key (element name) unless convention's NoRoot is set. Returns the first
+// error of w.
+func WriteJSON(w io.Writer, node *vector.Node, conv *JSONConvention) error {
+	if conv == nil {
+		conv = ConvDefault
+	}
+	ew := &errWriter{w: w}
+	if conv.NoRoot {
+		jsonElem(ew, node, conv)
+		return ew.err
+	}
+	_, _ = ew.Write(bjObjO)
+	writeJSONStr(ew, node.Key().Bytes())
+	_, _ = ew.Write(bjColon)
+	jsonElem(ew, node, conv)
+	_, _ = ew.Write(bjObjC)
+	return ew.err
+}
+
+// JSON writer of the element: stack of frames of open objects and shared storage of their grouped children.
+type jsonWriter struct {
+	w     io.Writer
+	conv  *JSONConvention
+	kids  []*vector.Node
+	ends  []int
+	stack []jsonFrame
+}
+
+// Frame of the element written as object.
+type jsonFrame struct {
+	// Offsets of element's children in kids and its groups in ends, see groupChildren.
+	kbase, gbase int
+	// Offsets of the next child, start of the current group and the current group.
+	pos, start, g int
+	// Count of written keys.
+	c int
+	// Element is an array and the current group is written as JSON array.
+	arr, multi bool
+}
+
+// Write element value. Errors of w are tracked by errWriter.
+//
+// Uses explicit stack of frames, so depth of the tree is limited by memory only.
+func jsonElem(w io.Writer, node *vector.Node, conv *JSONConvention) {
+	jw := jsonWriter{w: w, conv: conv}
+	jw.open(node)
+	for len(jw.stack) > 0 {
+		top := len(jw.stack) - 1
+		f := &jw.stack[top]
+		if f.pos > f.start && f.pos == jw.ends[f.g] {
+			// The current group is complete.
+			if f.multi {
+				_, _ = w.Write(bjArrC)
+			}
+			f.g, f.start = f.g+1, f.pos
+		}
+		// Groups of the top frame are always at the end of storage.
+		if f.g == len(jw.ends) {
+			_, _ = w.Write(bjObjC)
+			jw.kids, jw.ends = jw.kids[:f.kbase], jw.ends[:f.gbase]
+			jw.stack = jw.stack[:top]
+			continue
+		}
+		child := jw.kids[f.pos]
+		if f.pos == f.start {
+			jw.key(f, "", child.Key().Bytes())
+			if f.multi = f.arr || jw.ends[f.g]-f.start > 1; f.multi {
+				_, _ = w.Write(bjArrO)
+			}
+		} else {
+			_, _ = w.Write(bjComma)
+		}
+		f.pos++
+		jw.open(child)
+	}
+}
+
+// Write the element as a scalar or open its object and push the frame.
+func (jw *jsonWriter) open(node *vector.Node) {
+	w, conv := jw.w, jw.conv
+	text := isText(node)
+	attrs := !conv.NoAttrs && hasAttrs(node)
+	kbase, gbase := len(jw.kids), len(jw.ends)
+	jw.kids, jw.ends = groupChildren(node, jw.kids, jw.ends)
+	if !attrs && len(jw.ends) == gbase && !conv.TextObject {
+		if text {
+			writeJSONStr(w, node.Value().Bytes())
+		} else {
+			_, _ = w.Write(bjNull)
+		}
+		return
+	}
+
+	_, _ = w.Write(bjObjO)
+	f := jsonFrame{kbase: kbase, gbase: gbase, pos: kbase, start: kbase, g: gbase, arr: node.Type() == vector.TypeArray}
+	if attrs {
+		for _, attr := range Attrs(node) {
+			jw.key(&f, conv.AttrPrefix, attr.Key().Bytes())
+			writeJSONStr(w, attr.Bytes())
+		}
+	}
+	if text {
+		jw.key(&f, conv.TextKey, nil)
+		writeJSONStr(w, node.Value().Bytes())
+	}
+	jw.stack = append(jw.stack, f)
+}
+
+// Write key of the object.
+func (jw *jsonWriter) key(f *jsonFrame, prefix string, name []byte) {
+	w := jw.w
+	if f.c > 0 {
+		_, _ = w.Write(bjComma)
+	}
+	f.c++
+	_, _ = w.Write(bjQuote)
+	writeJSONEscape(w, byteconv.S2B(prefix))
+	writeJSONEscape(w, name)
+	_, _ = w.Write(bjQuote)
+	_, _ = w.Write(bjColon)
+}
+
+// Write p as quoted JSON string.
+func writeJSONStr(w io.Writer, p []byte) {
+	_, _ = w.Write(bjQuote)
+	writeJSONEscape(w, p)
+	_, _ = w.Write(bjQuote)
+}
+
+// Write p escaped for JSON string.
+func writeJSONEscape(w io.Writer, p []byte) {
+	var off int
+	for i := 0; i < len(p); i++ {
+		if e := jsonEscTable[p[i]]; e != nil {
+			_, _ = w.Write(p[off:i])
+			_, _ = w.Write(e)
+			off = i + 1
+		}
+	}
+	_, _ = w.Write(p[off:])
+}
@@ -0,0 +1,201 @@
+package xmlvector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/koykov/vector"
+)
+
+// ParseJSON builds the document from JSON source using given convention (ConvDefault if nil).
+//
+// Unless convention's NoRoot is set, source must be an object with single key (root element name). Source is read
+// using encoding/json tokenizer, so keys order is kept and no extra dependencies are required.
+func (vec *Vector) ParseJSON(src []byte, conv *JSONConvention) error {
+	if conv == nil {
+		conv = ConvDefault
+	}
+	p := jsonParser{
+		dec:  json.NewDecoder(bytes.NewReader(src)),
+		b:    NewBuilder(vec),
+		conv: conv,
+	}
+	p.dec.UseNumber()
+	if err := p.parse(); err != nil {
+		return err
+	}
+	return p.b.Finish()
+}
+
+// JSONToXML converts JSON source to XML and writes it to w. See ParseJSON for details.
+func JSONToXML(w io.Writer, src []byte, conv *JSONConvention) error {
+	vec := Acquire()
+	defer Release(vec)
+	if err := vec.ParseJSON(src, conv); err != nil {
+		return err
+	}
+	return vec.Marshal(w)
+}
+
+// JSON to XML converter state.
+type jsonParser struct {
+	dec  *json.Decoder
+	b    *Builder
+	conv *JSONConvention
+}
+
+func (p *jsonParser) parse() (err error) {
+	var tok json.Token
+	if p.conv.NoRoot {
+		root := p.conv.Root
+		if len(root) == 0 {
+			root = "root"
+		}
+		if tok, err = p.dec.Token(); err != nil {
+			return
+		}
+		if err = p.elem(root, tok); err != nil {
+			return
+		}
+	} else {
+		if tok, err = p.dec.Token(); err != nil {
+			return
+		}
+		if tok != json.Delim('{') {
+			return ErrNoRoot
+		}
+		if tok, err = p.dec.Token(); err != nil {
+			return
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return ErrNoRoot
+		}
+		if tok, err = p.dec.Token(); err != nil {
+			return
+		}
+		if err = p.elem(key, tok); err != nil {
+			return
+		}
+		if tok, err = p.dec.Token(); err != nil {
+			return
+		}
+		if tok != json.Delim('}') {
+			return ErrMultiRoot
+		}
+	}
+	if _, err = p.dec.Token(); err != io.EOF {
+		return vector.ErrUnparsedTail
+	}
+	return nil
+}
+
+// Build element with given name and value (started with tok).
+func (p *jsonParser) elem(name string, tok json.Token) (err error) {
+	if !isName(name) {
+		return ErrBadName
+	}
+	p.b.Element(name)
+	switch tok {
+	case json.Delim('{'):
+		err = p.object()
+	case json.Delim('['):
+		err = ErrUnexpToken
+	case nil:
+	default:
+		p.b.Text(jsonScalar(tok))
+	}
+	if err != nil {
+		return
+	}
+	return p.b.End().Err()
+}
+
+// Build content of the current element from JSON object.
+func (p *jsonParser) object() error {
+	conv := p.conv
+	var arr bool
+	for p.dec.More() {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if tok, err = p.dec.Token(); err != nil {
+			return err
+		}
+		_, delim := tok.(json.Delim)
+		switch {
+		case len(conv.TextKey) > 0 && key == conv.TextKey && !delim:
+			p.b.Text(jsonScalar(tok))
+		case !conv.NoAttrs && len(conv.AttrPrefix) > 0 && strings.HasPrefix(key, conv.AttrPrefix) && !delim:
+			err = p.attr(key[len(conv.AttrPrefix):], jsonScalar(tok))
+		case !conv.NoAttrs && len(conv.AttrPrefix) == 0 && conv.TextObject && !delim:
+			err = p.attr(key, jsonScalar(tok))
+		case tok == json.Delim('['):
+			arr = true
+			for p.dec.More() {
+				if tok, err = p.dec.Token(); err != nil {
+					return err
+				}
+				if err = p.elem(key, tok); err != nil {
+					return err
+				}
+			}
+			if _, err = p.dec.Token(); err != nil {
+				return err
+			}
+		default:
+			if err = p.elem(key, tok); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.b.Err(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.dec.Token(); err != nil {
+		return err
+	}
+	if arr {
+		// Keep single-item arrays, element type will be checked on close.
+		p.b.current().SetType(vector.TypeArray)
+	}
+	return nil
+}
+
+// Add attribute to the current element. Attributes may follow element's content in JSON, so mutation API is used.
+func (p *jsonParser) attr(name, value string) error {
+	if p.b.err != nil {
+		return p.b.err
+	}
+	if !isName(name) {
+		return ErrBadName
+	}
+	vec, node := p.b.vec, p.b.current()
+	// Mark children range of new element as empty to avoid phantom child, see closeNode.
+	vec.closeNode(node)
+	vec.Node(node).SetAttr(name, value)
+	return nil
+}
+
+// Get string representation of JSON scalar.
+func jsonScalar(tok json.Token) string {
+	switch x := tok.(type) {
+	case string:
+		return x
+	case json.Number:
+		return string(x)
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package xmlvector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestJSON(t *testing.T) {
+	assertJSON := func(t *testing.T, vec *Vector, conv *JSONConvention, expect string) {
+		var buf bytes.Buffer
+		if err := vec.WriteJSON(&buf, conv); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expect {
+			t.Errorf("json mismatch, need\n%s\ngot\n%s", expect, buf.String())
+		}
+		if !json.Valid(buf.Bytes()) {
+			t.Error("invalid json")
+		}
+	}
+	vec := NewVector()
+	t.Run("array/mixed", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		assertJSON(t, vec, ConvDefault, `{"r":{"a":["1","2","4"],"b":"x","c":{"@id":"3"}}}`)
+		assertJSON(t, vec, ConvParker, `{"a":["1","2","4"],"b":"x","c":null}`)
+		assertJSON(t, vec, ConvBadgerFish, `{"r":{"a":[{"$":"1"},{"$":"2"},{"$":"4"}],"b":{"$":"x"},"c":{"@id":"3"}}}`)
+		assertJSON(t, vec, ConvGData, `{"r":{"a":[{"$t":"1"},{"$t":"2"},{"$t":"4"}],"b":{"$t":"x"},"c":{"id":"3"}}}`)
+	})
+	t.Run("array/single", func(t *testing.T) {
+		vec.SetForceArray("CD")
+		assertParse(t, vec, nil, 0)
+		vec.SetForceArray()
+		var buf bytes.Buffer
+		_ = WriteJSON(&buf, vec.Dot("CATALOG"), ConvParker)
+		expect := `{"CD":[{"TITLE":"Empire Burlesque","ARTIST":"Bob Dylan","TRACKS":{"TRACK":"Tight Connection to My Heart"}}]}`
+		if buf.String() != expect {
+			t.Errorf("json mismatch, need\n%s\ngot\n%s", expect, buf.String())
+		}
+	})
+	t.Run("root/cdata", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		assertJSON(t, vec, &JSONConvention{AttrPrefix: "-", TextKey: "text"},
+			`{"movie":{"raw":"Marquis Warren","cdata":"<strong>Main protagonist<strong> of \"The Hateful Eight\""}}`)
+	})
+	t.Run("root/collapsed", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		assertJSON(t, vec, nil, `{"root":null}`)
+		assertJSON(t, vec, ConvBadgerFish, `{"root":{}}`)
+	})
+	t.Run("attr/text", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseCopyString(`<r a="1"><y b="2">v</y></r>`); err != nil {
+			t.Fatal(err)
+		}
+		assertJSON(t, vec, ConvDefault, `{"r":{"@a":"1","y":{"@b":"2","#text":"v"}}}`)
+		assertJSON(t, vec, ConvBadgerFish, `{"r":{"@a":"1","y":{"@b":"2","$":"v"}}}`)
+		assertJSON(t, vec, ConvGData, `{"r":{"a":"1","y":{"b":"2","$t":"v"}}}`)
+	})
+	t.Run("write", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseString(`<r><a x="1">foo</a><a>bar</a><b/></r>`); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_ = vec.WriteJSON(&buf, nil)
+		for n := 0; n < buf.Len(); n++ {
+			if err := vec.WriteJSON(&failWriter{n: n}, nil); err != errWrite {
+				t.Errorf("error mismatch at %d, got %v", n, err)
+			}
+		}
+		if err := vec.WriteJSON(&failWriter{n: buf.Len()}, nil); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func BenchmarkJSON(b *testing.B) {
+	b.Run("root/array", func(b *testing.B) {
+		var buf bytes.Buffer
+		bench(b, func(vec *Vector) {
+			buf.Reset()
+			_ = vec.WriteJSON(&buf, ConvBadgerFish)
+		})
+	})
+}
+
+func TestParseJSON(t *testing.T) {
+	convs := []*JSONConvention{ConvDefault, ConvBadgerFish, ConvGData, {NoAttrs: true, NoRoot: true, Root: "r"}}
+	assertRoundTrip := func(t *testing.T) {
+		src := NewVector()
+		assertParse(t, src, nil, 0)
+		for _, conv := range convs {
+			var a, b bytes.Buffer
+			_ = src.WriteJSON(&a, conv)
+			vec := NewVector()
+			if err := vec.ParseJSON(a.Bytes(), conv); err != nil {
+				t.Fatal(err)
+			}
+			_ = vec.WriteJSON(&b, conv)
+			if !bytes.Equal(a.Bytes(), b.Bytes()) {
+				t.Errorf("round trip mismatch, need\n%s\ngot\n%s", a.String(), b.String())
+			}
+		}
+	}
+	t.Run("root/array", assertRoundTrip)
+	t.Run("root/attr", assertRoundTrip)
+	t.Run("root/cdata", assertRoundTrip)
+	t.Run("array/mixed", assertRoundTrip)
+	t.Run("attr/text", func(t *testing.T) {
+		for _, c := range []struct {
+			conv *JSONConvention
+			src  string
+		}{
+			{ConvDefault, `{"r":{"@a":"1","y":{"@b":"2","#text":"v"}}}`},
+			{ConvBadgerFish, `{"r":{"@a":"1","y":{"@b":"2","$":"v"}}}`},
+			{ConvGData, `{"r":{"a":"1","y":{"b":"2","$t":"v"}}}`},
+		} {
+			var buf bytes.Buffer
+			if err := JSONToXML(&buf, []byte(c.src), c.conv); err != nil {
+				t.Fatal(err)
+			}
+			if s := buf.String(); s != `<?xml version="1.0"?><r a="1"><y b="2">v</y></r>` {
+				t.Error("xml mismatch, got", s)
+			}
+		}
+	})
+	t.Run("array/single", func(t *testing.T) {
+		vec := NewVector()
+		err := vec.ParseJSON([]byte(`{"CATALOG":{"CD":[{"TITLE":"Empire Burlesque","YEAR":1985,"@id":1}]}}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "CATALOG", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.0.YEAR", "1985", vector.TypeString)
+		assertStr(t, vec, "CATALOG.0@id", "1", vector.TypeAttribute)
+	})
+	t.Run("xml", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := JSONToXML(&buf, []byte(`{"a":{"@x":"1 & 2","#text":"<foo>"}}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.String(); s != `<?xml version="1.0"?><a x="1 &amp; 2">&lt;foo&gt;</a>` {
+			t.Error("xml mismatch, got", s)
+		}
+		if err = JSONToXML(&buf, []byte(`{"a":1,"b":2}`), nil); err != ErrMultiRoot {
+			t.Error("error mismatch, got", err)
+		}
+	})
+	t.Run("name", func(t *testing.T) {
+		for _, src := range []string{
+			`{"r":{"foo bar":"1"}}`,
+			`{"1r":"x"}`,
+			`{"r":{"@a<b":"1"}}`,
+			`{"r":{"":"1"}}`,
+		} {
+			if err := JSONToXML(io.Discard, []byte(src), nil); err != ErrBadName {
+				t.Errorf("error mismatch for %s, got %v", src, err)
+			}
+		}
+		if err := JSONToXML(io.Discard, []byte(`{"r":{"a":"1","x-y.z":"2","b":"3"}}`), ConvGData); err != nil {
+			t.Error(err)
+		}
+		if err := JSONToXML(io.Discard, []byte(`{"r":{"a b":"1"}}`), ConvGData); err != ErrBadName {
+			t.Error("error mismatch, got", err)
+		}
+	})
+}
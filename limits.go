@@ -0,0 +1,32 @@
+package xmlvector
+
+// Limits describes restrictions of the parsed source. Zero value of any field means no limit.
+//
+// Violation of any limit stops parsing with corresponding error even in recovery mode.
+type Limits struct {
+	// MaxDepth is a max nesting depth of elements, root element has depth 1 (ErrMaxDepth).
+	MaxDepth int
+	// MaxAttrs is a max count of attributes of an element (ErrMaxAttrs).
+	MaxAttrs int
+	// MaxNameLen is a max length of element or attribute name in bytes (ErrLongName).
+	MaxNameLen int
+	// MaxNodes is a max total count of nodes including document root and prolog attributes (ErrMaxNodes).
+	MaxNodes int
+	// MaxTextLen is a max length of raw text or attribute value in bytes (ErrLongText).
+	MaxTextLen int
+}
+
+// SetLimits sets restrictions of the parsed source.
+func (vec *Vector) SetLimits(limits Limits) *Vector {
+	vec.limits = limits
+	return vec
+}
+
+// Check if error is a limit violation.
+func isLimitErr(err error) bool {
+	switch err {
+	case ErrMaxDepth, ErrMaxAttrs, ErrLongName, ErrMaxNodes, ErrLongText:
+		return true
+	}
+	return false
+}
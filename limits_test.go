@@ -0,0 +1,134 @@
+package xmlvector
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestLimits(t *testing.T) {
+	type stage struct {
+		name   string
+		src    string
+		limits Limits
+		err    error
+		offset int
+	}
+	stages := []stage{
+		{"depth", `<a><b><c/></b></a>`, Limits{MaxDepth: 2}, ErrMaxDepth, 6},
+		{"attrs", `<a x="1" y="2" z="3"/>`, Limits{MaxAttrs: 2}, ErrMaxAttrs, 15},
+		{"elemName", `<abcdef/>`, Limits{MaxNameLen: 5}, ErrLongName, 1},
+		{"attrName", `<a abcdef="1"/>`, Limits{MaxNameLen: 5}, ErrLongName, 3},
+		{"nodes", `<a><b/><c/><d/></a>`, Limits{MaxNodes: 4}, ErrMaxNodes, 8},
+		{"text", `<a>foobar</a>`, Limits{MaxTextLen: 5}, ErrLongText, 3},
+		{"attrValue", `<a x="foobar"/>`, Limits{MaxTextLen: 5}, ErrLongText, 6},
+		{"ok", `<a x="1"><b>foo</b></a>`, Limits{MaxDepth: 2, MaxAttrs: 1, MaxNameLen: 1, MaxNodes: 5, MaxTextLen: 3}, nil, 0},
+	}
+	vec := NewVector()
+	for _, st := range stages {
+		t.Run(st.name, func(t *testing.T) {
+			vec.Reset()
+			vec.SetLimits(st.limits)
+			defer vec.SetLimits(Limits{})
+			err := vec.ParseCopyString(st.src)
+			if err != st.err {
+				t.Fatalf("error mismatch, need %v got %v", st.err, err)
+			}
+			if err != nil && vec.ErrorOffset() != st.offset {
+				t.Errorf("error offset mismatch, need %d got %d", st.offset, vec.ErrorOffset())
+			}
+		})
+	}
+	t.Run("recover", func(t *testing.T) {
+		vec.Reset()
+		vec.SetRecover(true).SetLimits(Limits{MaxDepth: 1})
+		defer func() { vec.SetRecover(false).SetLimits(Limits{}) }()
+		if err := vec.ParseCopyString(`<a><b/></a>`); err != ErrMaxDepth {
+			t.Errorf("error mismatch, need %v got %v", ErrMaxDepth, err)
+		}
+		vec.Reset()
+		if err := vec.ParseFragmentCopy([]byte(`<a/><b><c/></b>`)); err != ErrMaxDepth {
+			t.Errorf("fragment error mismatch, need %v got %v", ErrMaxDepth, err)
+		}
+	})
+	t.Run("deep", func(t *testing.T) {
+		// Nesting depth isn't limited by the stack.
+		const depth = 1e5
+		defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+		src := strings.Repeat("<a>", depth) + "x" + strings.Repeat("</a>", depth)
+		vec.Reset()
+		if err := vec.ParseCopyString(src); err != nil {
+			t.Fatal(err)
+		}
+		node := vec.Root()
+		for i := 0; i < depth; i++ {
+			node = node.Get("a")
+		}
+		if node.String() != "x" || node.Type() != vector.TypeString {
+			t.Error("deepest element mismatch")
+		}
+		// Traversals aren't limited by the stack too.
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		if buf.String() != `<?xml version="1.0"?>`+src {
+			t.Error("marshal mismatch")
+		}
+		buf.Reset()
+		_ = vec.WriteJSON(&buf, nil)
+		if buf.String() != strings.Repeat(`{"a":`, depth)+`"x"`+strings.Repeat("}", depth) {
+			t.Error("json mismatch")
+		}
+		var c, d int
+		vec.Walk(func(_ *vector.Node, info *WalkInfo) WalkAction {
+			if info.Kind == KindElement {
+				c++
+			}
+			return WalkContinue
+		}, func(_ *vector.Node, info *WalkInfo) WalkAction {
+			d = max(d, info.Depth)
+			return WalkContinue
+		})
+		if c != depth || d != depth-1 {
+			t.Errorf("walk mismatch, got %d elements and depth %d", c, d)
+		}
+		c = 0
+		for range Descendants(vec.Root()) {
+			c++
+		}
+		if c != depth {
+			t.Error("descendants count mismatch, got", c)
+		}
+		// Root, version attribute and elements.
+		if vec.Len() != depth+2 {
+			t.Error("nodes count mismatch, got", vec.Len())
+		}
+		// Nodes don't keep the vector alive, see NewVector.
+		runtime.KeepAlive(vec)
+
+		// Count of nodes above matches MaxNodes exactly.
+		vec.Reset()
+		vec.SetLimits(Limits{MaxNodes: depth + 2})
+		defer vec.SetLimits(Limits{})
+		if err := vec.ParseCopyString(src); err != nil {
+			t.Error(err)
+		}
+		vec.Reset()
+		vec.SetLimits(Limits{MaxNodes: depth + 1})
+		if err := vec.ParseCopyString(src); err != ErrMaxNodes {
+			t.Errorf("error mismatch, need %v got %v", ErrMaxNodes, err)
+		}
+	})
+	t.Run("pool", func(t *testing.T) {
+		var p Pool
+		p.SetLimits(Limits{MaxDepth: 1})
+		vec := p.Get()
+		defer p.Put(vec)
+		if err := vec.ParseString(`<a><b/></a>`); err != ErrMaxDepth {
+			t.Errorf("error mismatch, need %v got %v", ErrMaxDepth, err)
+		}
+	})
+}
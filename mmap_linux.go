@@ -0,0 +1,54 @@
+//go:build linux
+
+package xmlvector
+
+import (
+	"os"
+	"syscall"
+)
+
+// ParseFileMmap maps file contents to memory and parse it without copying to the vector buffer.
+//
+// Nodes refer to the mapping, so they (and any bytes taken from them) become invalid after Reset or Release, which
+// unmap the file. The vector mapped a file must be reset or released to free the mapping. Mapping is private, so
+// in-place modifications (eg: unescaping, lowercase names in HTML mode) don't affect the file.
+//
+// Compressed file (see SetDecompress) is parsed using ParseFile.
+func (vec *Vector) ParseFileMmap(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+	if size == 0 {
+		// Empty file can't be mapped.
+		return vec.parse(nil, false, false)
+	}
+	if int64(int(size)) != size {
+		return vec.ParseFile(path)
+	}
+	m, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return err
+	}
+	if vec.decompress && lookupDecompressor(m[:min(len(m), maxMagic)]) != nil {
+		_ = syscall.Munmap(m)
+		return vec.ParseFile(path)
+	}
+	vec.mmaps = append(vec.mmaps, m)
+	return vec.parse(m, false, false)
+}
+
+// Unmap files mapped by ParseFileMmap.
+func (vec *Vector) unmap() {
+	for i := 0; i < len(vec.mmaps); i++ {
+		_ = syscall.Munmap(vec.mmaps[i])
+		vec.mmaps[i] = nil
+	}
+	vec.mmaps = vec.mmaps[:0]
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package xmlvector
+
+// ParseFileMmap reads file contents and parse it. Memory mapping is available only on Linux, see ParseFile.
+func (vec *Vector) ParseFileMmap(path string) error {
+	return vec.ParseFile(path)
+}
+
+func (vec *Vector) unmap() {}
@@ -0,0 +1,56 @@
+package xmlvector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestParseFileMmap(t *testing.T) {
+	vec := NewVector()
+	t.Run("file", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseFileMmap("testdata/root/array.xml"); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "CATALOG.CD", vector.TypeArray)
+		vec.Reset()
+	})
+	t.Run("private", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "page.html")
+		src := []byte(`<HTML><BODY>a &amp; b</BODY></HTML>`)
+		_ = os.WriteFile(path, src, 0644)
+		vec.Reset()
+		vec.SetHTML(true)
+		defer vec.SetHTML(false)
+		if err := vec.ParseFileMmap(path); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "html.body", "a & b", vector.TypeString)
+		vec.Reset()
+		// In-place modifications must not affect the file.
+		if raw, _ := os.ReadFile(path); string(raw) != string(src) {
+			t.Error("file was modified:", string(raw))
+		}
+	})
+	t.Run("compressed", func(t *testing.T) {
+		vec.Reset()
+		vec.SetDecompress(true)
+		defer vec.SetDecompress(false)
+		if err := vec.ParseFileMmap("testdata/compress/array.xml.gz"); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "CATALOG.CD", vector.TypeArray)
+		vec.Reset()
+	})
+	t.Run("empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "empty.xml")
+		_ = os.WriteFile(path, nil, 0644)
+		vec.Reset()
+		if err := vec.ParseFileMmap(path); err != vector.ErrEmptySrc {
+			t.Errorf("error mismatch, need %v got %v", vector.ErrEmptySrc, err)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package xmlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/vector"
+)
+
+// Node is a mutable wrapper of the vector node.
+//
+// Wrapper addresses the node by its index, so it remains valid even if vector's nodes array grows due to mutations.
+// All new bytes (names, values and texts) are copied to the vector's storage, so caller may reuse them.
+type Node struct {
+	vec *Vector
+	idx int
+}
+
+// Node wraps node to mutable wrapper.
+func (vec *Vector) Node(node *vector.Node) Node {
+	if vec.NodeAt(node.Index()) != node {
+		return Node{vec: vec, idx: -1}
+	}
+	return Node{vec: vec, idx: node.Index()}
+}
+
+// Raw returns underlying vector node.
+//
+// Note, the pointer becomes invalid after any mutation that adds new nodes.
+func (n Node) Raw() *vector.Node {
+	if n.vec == nil {
+		return nil
+	}
+	return n.vec.NodeAt(n.idx)
+}
+
+// Valid checks if wrapper points to the element or attribute of the vector.
+func (n Node) Valid() bool {
+	if n.vec == nil || n.idx < 0 || n.idx >= n.vec.Len() {
+		return false
+	}
+	typ := n.vec.NodeAt(n.idx).Type()
+	return typ != vector.TypeNull && typ != vector.TypeUnknown
+}
+
+// SetText replaces element's content with text. Element children are removed, attributes are kept.
+//
+// For attribute nodes sets the value of attribute.
+func (n Node) SetText(text string) Node {
+	if !n.Valid() {
+		return n
+	}
+	vec, node := n.vec, n.Raw()
+	setValue(node.Value(), vec.cbuf.bufferizeString(text))
+	if node.Type() == vector.TypeAttribute {
+		return n
+	}
+	// Keep attributes only.
+	ci, off := node.ChildrenIndices(), node.Offset()
+	row := vec.Index.GetRow(node.Depth() + 1)
+	var c int
+	for i := 0; i < len(ci); i++ {
+		if j := ci[i]; vec.NodeAt(j).Type() == vector.TypeAttribute {
+			row[off+c], row[off+i] = j, row[off+c]
+			c++
+		}
+	}
+	setRange(node, off, off+c)
+	if c > 0 {
+		node.SetType(vector.TypeObject)
+	} else {
+		node.SetType(vector.TypeString)
+	}
+	return n
+}
+
+// SetAttr sets value of the attribute. Attribute will be added if it doesn't exist.
+func (n Node) SetAttr(name, value string) Node {
+	if !n.Valid() || n.Raw().Type() == vector.TypeAttribute {
+		return n
+	}
+	vec := n.vec
+	if attr := n.attr(name); attr != nil {
+		setValue(attr.Value(), vec.cbuf.bufferizeString(value))
+		return n
+	}
+	var nattr int
+	for range Attrs(n.Raw()) {
+		nattr++
+	}
+	attr, ai := vec.AcquireNodeWithType(n.Raw().Depth()+1, vector.TypeAttribute)
+	vec.setParent(ai, n.idx)
+	attr.Key().Init(vec.cbuf.bufferizeString(name), 0, len(name))
+	setValue(attr.Value(), vec.cbuf.bufferizeString(value))
+	vec.insertChild(n.Raw(), nattr, ai)
+
+	node := n.Raw()
+	node.Key().SetBit(flagAttr, true)
+	if node.Type() == vector.TypeString {
+		node.SetType(vector.TypeObject)
+	}
+	return n
+}
+
+// RemoveAttr removes attribute with given name. Returns false if attribute doesn't exist.
+func (n Node) RemoveAttr(name string) bool {
+	if !n.Valid() {
+		return false
+	}
+	attr := n.attr(name)
+	if attr == nil {
+		return false
+	}
+	return n.vec.Node(attr).Remove()
+}
+
+// AppendChild adds new empty element with given name to the end of element's children and returns it.
+func (n Node) AppendChild(name string) Node {
+	return n.InsertChild(-1, name)
+}
+
+// InsertChild adds new empty element with given name at position pos of element's children and returns it.
+//
+// Negative or overflowed position means the end of children list. Text content of the element (if any) is removed,
+// since mixed content isn't supported.
+func (n Node) InsertChild(pos int, name string) Node {
+	if !n.Valid() || n.Raw().Type() == vector.TypeAttribute {
+		return Node{vec: n.vec, idx: -1}
+	}
+	vec := n.vec
+	var nattr, nelem int
+	for range Attrs(n.Raw()) {
+		nattr++
+	}
+	for range Children(n.Raw()) {
+		nelem++
+	}
+	if pos < 0 || pos > nelem {
+		pos = nelem
+	}
+
+	child, ci := vec.AcquireNodeWithType(n.Raw().Depth()+1, vector.TypeObject)
+	vec.setParent(ci, n.idx)
+	child.Key().Init(vec.cbuf.bufferizeString(name), 0, len(name))
+	setRange(child, 0, 0)
+	vec.insertChild(n.Raw(), nattr+pos, ci)
+
+	node := n.Raw()
+	if node.Type() != vector.TypeArray {
+		node.SetType(vector.TypeObject)
+		node.Value().SetLen(0)
+	}
+	vec.checkArr(node)
+	return Node{vec: vec, idx: ci}
+}
+
+// Remove removes the node (with its subtree) from the parent. Returns false if node has no parent.
+func (n Node) Remove() bool {
+	if !n.Valid() {
+		return false
+	}
+	vec, node := n.vec, n.Raw()
+	parent := vec.Parent(node)
+	pos := childPos(parent, node)
+	if pos == -1 {
+		return false
+	}
+	ci, off := parent.ChildrenIndices(), parent.Offset()
+	row := vec.Index.GetRow(parent.Depth() + 1)
+	// Move removed entry to the end of range and exclude it.
+	copy(row[off+pos:], row[off+pos+1:off+len(ci)])
+	row[off+len(ci)-1] = node.Index()
+	setRange(parent, off, off+len(ci)-1)
+	vec.setParent(node.Index(), -1)
+
+	if node.Type() != vector.TypeAttribute {
+		vec.checkArr(parent)
+		return true
+	}
+	if hasAttrs(parent) {
+		return true
+	}
+	parent.Key().SetBit(flagAttr, false)
+	if parent.Type() == vector.TypeObject && parent.Value().Len() > 0 {
+		parent.SetType(vector.TypeString)
+	}
+	return true
+}
+
+// Find attribute node by name.
+func (n Node) attr(name string) *vector.Node {
+	for _, attr := range Attrs(n.Raw()) {
+		if attr.Key().RawString() == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// Insert node with index i to children of node at position pos (including attributes).
+//
+// Children range of the node relocates to the end of the index row if it isn't already there.
+func (vec *Vector) insertChild(node *vector.Node, pos, i int) {
+	depth := node.Depth() + 1
+	ci, off := node.ChildrenIndices(), node.Offset()
+	n := len(ci)
+	// Note, index i already registered at the end of the row.
+	switch l := vec.Index.Len(depth); {
+	case n == 0:
+		off = l - 1
+	case off+n != l-1:
+		off = l
+		for j := 0; j < n; j++ {
+			vec.Index.Register(depth, ci[j])
+		}
+		vec.Index.Register(depth, i)
+	}
+	row := vec.Index.GetRow(depth)
+	copy(row[off+pos+1:off+n+1], row[off+pos:off+n])
+	row[off+pos] = i
+	setRange(node, off, off+n+1)
+}
+
+// Check array state of the node after mutation of children list.
+//
+// Node with homogeneous children becomes an array (or remains an array), node with mixed children (or comments) becomes
+// an object.
+func (vec *Vector) checkArr(node *vector.Node) {
+	var (
+		pk    *vector.Byteptr
+		cnt   int
+		mixed bool
+	)
+	eachChild(node, childElem|childComment, func(_ int, child *vector.Node) bool {
+		switch {
+		case childKind(child) == childComment:
+			// Comments take positions in array, so node with comments can't be an array.
+			mixed = true
+		case pk == nil:
+			pk = child.Key()
+		case !bytes.Equal(child.Key().RawBytes(), pk.RawBytes()):
+			mixed = true
+		}
+		cnt++
+		return true
+	})
+	if !mixed && (cnt > 1 || cnt == 1 && node.Type() == vector.TypeArray) {
+		node.SetType(vector.TypeArray)
+		*node.Value() = *pk
+		node.Value().SetBit(flagAlias, true)
+		return
+	}
+	if node.Type() == vector.TypeArray {
+		node.SetType(vector.TypeObject)
+		node.Value().SetLen(0)
+		node.Value().SetBit(flagAlias, false)
+	}
+}
+
+// Check if node has at least one attribute.
+func hasAttrs(node *vector.Node) bool {
+	for range Attrs(node) {
+		return true
+	}
+	return false
+}
+
+// Set new value of byteptr and drop escape/alias/CDATA flags.
+func setValue(p *vector.Byteptr, b []byte) {
+	p.Init(b, 0, len(b))
+	p.SetBit(flagEscape, false)
+	p.SetBit(flagAlias, false)
+	p.SetBit(flagCDATA, false)
+	p.SetBit(flagNormEOL, false)
+	p.SetBit(flagNormAttr, false)
+	p.SetBit(flagNormTok, false)
+}
+
+// Set children range of the node. Empty range is marked with non-zero offset (see closeNode).
+func setRange(node *vector.Node, off, lim int) {
+	if off == lim && off == 0 {
+		off, lim = 1, 1
+	}
+	node.SetOffset(off).SetLimit(lim)
+}
@@ -0,0 +1,81 @@
+package xmlvector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestMutate(t *testing.T) {
+	vec := NewVector()
+	assertMarshal := func(t *testing.T, expect string) {
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		if buf.String() != expect {
+			t.Errorf("marshal mismatch, need\n%s\ngot\n%s", expect, buf.String())
+		}
+	}
+	t.Run("root/object", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		note := vec.Node(vec.Dot("note"))
+		vec.Node(vec.Dot("note.to")).SetText("Alice & Bob")
+		note.SetAttr("lang", "en").SetAttr("id", "1").SetAttr("lang", "fr")
+		note.InsertChild(0, "date").SetAttr("tz", "UTC").SetText("2024-01-01")
+		note.AppendChild("cc").SetText("Carol")
+		if !vec.Node(vec.Dot("note.heading")).Remove() {
+			t.Error("remove failed")
+		}
+		if !note.RemoveAttr("id") || note.RemoveAttr("id") {
+			t.Error("remove attribute failed")
+		}
+		assertStr(t, vec, "note.to", "Alice & Bob", vector.TypeString)
+		assertStr(t, vec, "note@lang", "fr", vector.TypeAttribute)
+		assertStr(t, vec, "note.date", "2024-01-01", vector.TypeObject)
+		assertStr(t, vec, "note.cc", "Carol", vector.TypeString)
+		assertType(t, vec, "note.heading", vector.TypeNull)
+		if p := vec.XPath(vec.Dot("note.cc")); p != "/note/cc" {
+			t.Error("xpath mismatch, got", p)
+		}
+		assertMarshal(t, `<?xml version="1.0" encoding="UTF-8"?><note lang="fr"><date tz="UTC">2024-01-01</date>`+
+			`<to>Alice &amp; Bob</to><from>Jani</from><body>Don't forget me this weekend!</body><cc>Carol</cc></note>`)
+
+		vec.Node(vec.Dot("note.date")).RemoveAttr("tz")
+		assertType(t, vec, "note.date", vector.TypeString)
+		note.SetText("empty")
+		assertMarshal(t, `<?xml version="1.0" encoding="UTF-8"?><note lang="fr">empty</note>`)
+	})
+	t.Run("root/array", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		catalog := vec.Node(vec.Dot("CATALOG"))
+		for i := 0; i < 25; i++ {
+			vec.Node(vec.Dot("CATALOG.0")).Remove()
+		}
+		assertType(t, vec, "CATALOG", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.0.TITLE", "Unchain my heart", vector.TypeString)
+
+		cd := catalog.AppendChild("CD")
+		cd.AppendChild("TITLE").SetText("Blonde on Blonde")
+		assertType(t, vec, "CATALOG", vector.TypeArray)
+		assertStr(t, vec, "CATALOG.1.TITLE", "Blonde on Blonde", vector.TypeString)
+		if p := vec.Path(vec.Dot("CATALOG.1.TITLE")); p != "CATALOG.1.TITLE" {
+			t.Error("path mismatch, got", p)
+		}
+
+		catalog.InsertChild(0, "DVD")
+		assertType(t, vec, "CATALOG", vector.TypeObject)
+		assertStr(t, vec, "CATALOG.CD.TITLE", "Unchain my heart", vector.TypeString)
+		vec.Node(vec.Dot("CATALOG.DVD")).Remove()
+		assertType(t, vec, "CATALOG", vector.TypeArray)
+	})
+	t.Run("cdata", func(t *testing.T) {
+		// Parsed CDATA section is written back as is, but new text is escaped.
+		vec.Reset()
+		if err := vec.ParseCopyString(`<a><b><![CDATA[<x>]]></b><c><![CDATA[<y>]]></c></a>`); err != nil {
+			t.Fatal(err)
+		}
+		vec.Node(vec.Dot("a.c")).SetText("1 < 2")
+		assertStr(t, vec, "a.c", "1 < 2", vector.TypeString)
+		assertMarshal(t, `<?xml version="1.0"?><a><b><![CDATA[<x>]]></b><c>1 &lt; 2</c></a>`)
+	})
+}
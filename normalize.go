@@ -0,0 +1,75 @@
+package xmlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+)
+
+// Normalize line endings (see XML spec, section 2.11) using p as a destination: CRLF and single CR replace with LF.
+func normalizeEOL(p []byte) []byte {
+	i := bytes.IndexByte(p, '\r')
+	if i == -1 {
+		return p
+	}
+	w := i
+	for r := i; r < len(p); r++ {
+		c := p[r]
+		if c == '\r' {
+			if r+1 < len(p) && p[r+1] == '\n' {
+				r++
+			}
+			c = '\n'
+		}
+		p[w] = c
+		w++
+	}
+	return p[:w]
+}
+
+// Normalize attribute value (see XML spec, section 3.3.3) using p as a destination: each whitespace (CRLF counts as
+// one) replaces with space.
+//
+// Must be applied before unescaping, since whitespaces produced by character references keep as is.
+func normalizeAttr(p []byte) []byte {
+	var w int
+	for r := 0; r < len(p); r++ {
+		c := p[r]
+		switch c {
+		case '\r':
+			if r+1 < len(p) && p[r+1] == '\n' {
+				r++
+			}
+			c = ' '
+		case '\n', '\t':
+			c = ' '
+		}
+		p[w] = c
+		w++
+	}
+	return p[:w]
+}
+
+// Normalize value of tokenized attribute (declared in DTD with type other than CDATA) using p as a destination:
+// leading and trailing spaces are discarded and sequences of spaces replace with single space.
+func normalizeTok(p []byte) []byte {
+	var w int
+	for r := 0; r < len(p); r++ {
+		if p[r] == ' ' && (w == 0 || p[w-1] == ' ') {
+			continue
+		}
+		p[w] = p[r]
+		w++
+	}
+	if w > 0 && p[w-1] == ' ' {
+		w--
+	}
+	return p[:w]
+}
+
+// Check if attribute value contains whitespaces to normalize.
+func checkNormAttr(p []byte) bool {
+	return bytealg.IndexAnyAtBytes(p, bAttrWS, 0) != -1
+}
+
+var bAttrWS = []byte("\t\n\r")
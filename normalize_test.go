@@ -0,0 +1,56 @@
+package xmlvector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestNormalize(t *testing.T) {
+	vec := NewVector()
+	t.Run("normalize/crlf", func(t *testing.T) {
+		st := getStage(getTBName(t))
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "note.to", "Tove", vector.TypeString)
+		assertStr(t, vec, "note.body", "line 1\nline 2\nline 3", vector.TypeString)
+		assertStr(t, vec, "note.code", "a\nb", vector.TypeString)
+
+		// Document with LF line endings must be parsed identically.
+		lf := NewVector()
+		_ = lf.Parse(bytes.ReplaceAll(st.origin, []byte("\r\n"), []byte("\n")))
+		var b0, b1 bytes.Buffer
+		_ = vec.Marshal(&b0)
+		_ = lf.Marshal(&b1)
+		if b0.String() != b1.String() {
+			t.Errorf("marshal mismatch:\n%s\n%s", b0.String(), b1.String())
+		}
+	})
+	t.Run("normalize/attr", func(t *testing.T) {
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "a@title", "foo bar baz", vector.TypeAttribute)
+		assertStr(t, vec, "a@ref", "x\ny", vector.TypeAttribute)
+		assertStr(t, vec, "a@tok", "  a  b  ", vector.TypeAttribute)
+	})
+	t.Run("normalize/dtd", func(t *testing.T) {
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "doc", "text", vector.TypeObject)
+		assertStr(t, vec, "doc@id", "d1", vector.TypeAttribute)
+		assertStr(t, vec, "doc@cls", "x y", vector.TypeAttribute)
+		assertStr(t, vec, "doc@kind", "a", vector.TypeAttribute)
+		assertStr(t, vec, "doc@note", "  n  ", vector.TypeAttribute)
+	})
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	b.Run("normalize/crlf", func(b *testing.B) {
+		bench(b, func(vec *Vector) {
+			assertStr(b, vec, "note.body", "line 1\nline 2\nline 3", vector.TypeString)
+		})
+	})
+	b.Run("normalize/dtd", func(b *testing.B) {
+		bench(b, func(vec *Vector) {
+			assertStr(b, vec, "doc@cls", "x y", vector.TypeAttribute)
+		})
+	})
+}
@@ -0,0 +1,445 @@
+package xmlvector
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// Default min size of the chunk, see Parallel.SetChunkSize.
+const defaultChunkSize = 64 * 1024
+
+// Parallel parses large documents with repeated records (children of the root element) using multiple goroutines.
+//
+// Content of the root element splits to chunks at start tags of records (comments, CDATA sections and processing
+// instructions are respected) and each chunk parses as a fragment (see Vector.ParseFragment) by own vector. Prolog
+// and the root element itself parse separately, see Head. Records are indexed in document order, so they're available
+// using Records and Record, and paths from the document root resolve through them, see Get.
+//
+// Limits (see Vector.SetLimits) apply to each chunk separately, source positions (see Vector.SetTrackPos) of records
+// refer to the chunk. HTML mode isn't supported.
+type Parallel struct {
+	workers int
+	chunk   int
+	setup   func(vec *Vector)
+
+	head   *Vector
+	vecs   []*Vector
+	errs   []error
+	bounds []int
+	buf    []byte
+	errOff int
+	// Records of all chunks in document order.
+	recs []*vector.Node
+	// Source has XML declaration, see Marshal.
+	prolog bool
+	// Buffer of keys, see Dot.
+	keys []string
+}
+
+// NewParallel makes new parallel parser with given count of goroutines (GOMAXPROCS if workers isn't positive).
+func NewParallel(workers int) *Parallel {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Parallel{workers: workers, chunk: defaultChunkSize, head: NewVector()}
+}
+
+// SetChunkSize sets min size of the chunk in bytes. Small documents parse by single goroutine.
+func (p *Parallel) SetChunkSize(size int) *Parallel {
+	p.chunk = size
+	return p
+}
+
+// SetSetup sets function to configure every vector (head and chunks) before parsing, eg: to set limits or entities.
+func (p *Parallel) SetSetup(fn func(vec *Vector)) *Parallel {
+	p.setup = fn
+	return p
+}
+
+// Parse parses source bytes. Source must be kept until Reset, since vectors refer to it.
+func (p *Parallel) Parse(s []byte) error {
+	p.Reset()
+	p.configure(p.head)
+	s, decoded, err := p.head.decode(s)
+	if err != nil {
+		return err
+	}
+	if decoded {
+		// Source is transcoded to UTF-8 entirely.
+		p.head.encOverride = encUTF8
+	}
+	t := bytealg.TrimBytesFmt4(s)
+	p.prolog = hasProlog(t)
+	c0, c1, err := rootContent(t)
+	if err != nil {
+		p.errOff = c0
+		return err
+	}
+
+	// Parse prolog and root element without content.
+	p.buf = append(append(p.buf[:0], t[:c0]...), t[c1:]...)
+	if err = p.head.Parse(p.buf); err != nil {
+		if p.errOff = p.head.ErrorOffset(); p.errOff >= c0 {
+			p.errOff += c1 - c0
+		}
+		return err
+	}
+	if c0 == c1 {
+		return nil
+	}
+
+	size := max(p.chunk, (c1-c0)/(p.workers*4))
+	p.bounds = splitContent(t, c0, c1, size, p.bounds)
+	return p.parseChunks(t)
+}
+
+// ParseString parses source string.
+func (p *Parallel) ParseString(s string) error {
+	return p.Parse(byteconv.S2B(s))
+}
+
+// Head returns vector contains prolog and the root element without content, see Root.
+func (p *Parallel) Head() *Vector {
+	return p.head
+}
+
+// Root returns the root element of the head vector.
+//
+// The root element has attributes, but no children: records are stored by separate vectors, so Children, Dot and other
+// lookups from the root node don't reach them. Use Get/Dot of the parser or Records to access records.
+func (p *Parallel) Root() *vector.Node {
+	var root *vector.Node
+	eachChild(p.head.Root(), childElem, func(_ int, child *vector.Node) bool {
+		root = child
+		return false
+	})
+	return root
+}
+
+// Records returns an iterator over records (element children of the root element) in document order.
+func (p *Parallel) Records() iter.Seq2[int, *vector.Node] {
+	return func(yield func(int, *vector.Node) bool) {
+		for i, rec := range p.recs {
+			if !yield(i, rec) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns count of records.
+func (p *Parallel) Len() int {
+	return len(p.recs)
+}
+
+// Record returns record by index in document order or nil if index is out of range.
+func (p *Parallel) Record(i int) *vector.Node {
+	if i < 0 || i >= len(p.recs) {
+		return nil
+	}
+	return p.recs[i]
+}
+
+// Get looks up node by keys from the document root like Vector.Get does, but children of the root element resolve
+// through records: numeric key addresses record by index and name addresses the first record with that name, eg:
+//
+//	p.Get("catalog", "3", "title") // title of the record #3
+//	p.Get("catalog", "item", "@id") // id of the first item
+//
+// Prolog attributes and attributes of the root element resolve by the head vector.
+func (p *Parallel) Get(keys ...string) *vector.Node {
+	if len(keys) < 2 || strings.HasPrefix(keys[1], "@") {
+		return p.head.Root().Get(keys...)
+	}
+	if root := p.Root(); root == nil || root.KeyString() != keys[0] {
+		return p.head.NodeAt(-1)
+	}
+	var rec *vector.Node
+	if i, err := strconv.Atoi(keys[1]); err == nil {
+		rec = p.Record(i)
+	} else {
+		for _, r := range p.recs {
+			if r.KeyString() == keys[1] {
+				rec = r
+				break
+			}
+		}
+	}
+	if rec == nil {
+		return p.head.NodeAt(-1)
+	}
+	return rec.Get(keys[2:]...)
+}
+
+// Dot looks up node by path with "." separator, see Get. Attribute keys may be separated by "@" only, eg:
+// "catalog.item@id".
+func (p *Parallel) Dot(path string) *vector.Node {
+	p.keys = p.keys[:0]
+	for len(path) > 0 {
+		i := strings.IndexAny(path[1:], ".@") + 1
+		if i == 0 {
+			i = len(path)
+		}
+		if key := strings.TrimPrefix(path[:i], "."); len(key) > 0 {
+			p.keys = append(p.keys, key)
+		}
+		path = path[i:]
+	}
+	return p.Get(p.keys...)
+}
+
+// ErrorOffset returns offset of the parsing error in the source.
+func (p *Parallel) ErrorOffset() int {
+	return p.errOff
+}
+
+// Marshal serializes the whole document (prolog, root element and records). Returns the first error of w.
+//
+// Prolog is written only if the source has XML declaration.
+func (p *Parallel) Marshal(w io.Writer) error {
+	root := p.Root()
+	if root == nil {
+		return ErrNoRoot
+	}
+	ew := &errWriter{w: w}
+	if p.prolog {
+		_, _ = ew.Write(bPrologOpen)
+		_ = btAttr(ew, p.head.Root())
+		_, _ = ew.Write(bPrologClose)
+	}
+	_, _ = ew.Write(btTagO)
+	_, _ = ew.Write(root.Key().Bytes())
+	_ = btAttr(ew, root)
+	_, _ = ew.Write(btTagC)
+	for _, rec := range p.Records() {
+		if err := serialize1(ew, rec, 2, false); err != nil {
+			return err
+		}
+	}
+	_, _ = ew.Write(bCTag)
+	_, _ = ew.Write(root.Key().Bytes())
+	_, _ = ew.Write(btTagC)
+	return ew.err
+}
+
+// Reset releases vectors of chunks and resets the head.
+func (p *Parallel) Reset() {
+	for i := 0; i < len(p.vecs); i++ {
+		Release(p.vecs[i])
+		p.vecs[i] = nil
+	}
+	p.vecs, p.errs = p.vecs[:0], p.errs[:0]
+	for i := 0; i < len(p.recs); i++ {
+		p.recs[i] = nil
+	}
+	p.recs = p.recs[:0]
+	p.prolog = false
+	p.head.Reset()
+	p.head.resetSettings()
+	p.errOff = 0
+}
+
+// Apply setup function to the vector.
+func (p *Parallel) configure(vec *Vector) {
+	if p.setup != nil {
+		p.setup(vec)
+	}
+}
+
+// Parse chunks using pool of goroutines.
+func (p *Parallel) parseChunks(src []byte) error {
+	n := len(p.bounds) - 1
+	for i := 0; i < n; i++ {
+		p.vecs = append(p.vecs, nil)
+		p.errs = append(p.errs, nil)
+	}
+	var (
+		wg   sync.WaitGroup
+		next atomic.Int64
+	)
+	for w := 0; w < min(p.workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				vec := Acquire()
+				p.configure(vec)
+				// Chunks don't contain DTD, so declared tokenized attributes are inherited from the head.
+				vec.dtdTok = append(vec.dtdTok, p.head.dtdTok...)
+				p.errs[i] = vec.ParseFragment(src[p.bounds[i]:p.bounds[i+1]])
+				p.vecs[i] = vec
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if err := p.errs[i]; err != nil {
+			chunk := src[p.bounds[i]:p.bounds[i+1]]
+			// Fragment parser trims leading whitespaces.
+			p.errOff = p.bounds[i] + len(chunk) - len(bytealg.TrimLeft(chunk, bFmt)) + p.vecs[i].ErrorOffset()
+			return err
+		}
+	}
+	for _, vec := range p.vecs {
+		eachChild(vec.Root(), childElem, func(_ int, rec *vector.Node) bool {
+			p.recs = append(p.recs, rec)
+			return true
+		})
+	}
+	return nil
+}
+
+// Find bounds of the root element content: offset after the start tag and offset of the close tag.
+//
+// Offsets are equal for empty root element (eg: <root/>). On error returns offset of the fault.
+func rootContent(src []byte) (int, int, error) {
+	var eof bool
+	n := len(src)
+	offset := 0
+	for {
+		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
+			return offset, offset, ErrNoRoot
+		}
+		switch {
+		case bytes.HasPrefix(src[offset:], bDocType):
+			end, _, _ := dtdBounds(src[offset:])
+			if end == -1 {
+				return offset, offset, vector.ErrUnexpEOF
+			}
+			offset += end
+			continue
+		case bytes.HasPrefix(src[offset:], bPIStart):
+			p := bytealg.IndexAtBytes(src, bPIClose, offset)
+			if p == -1 {
+				return offset, offset, vector.ErrUnexpEOF
+			}
+			offset = p + len(bPIClose)
+			continue
+		}
+		break
+	}
+	if src[offset] != '<' {
+		return offset, offset, ErrNoRoot
+	}
+	p := tagEnd(src, n, offset)
+	if p == -1 {
+		return offset, offset, ErrUnclosedTag
+	}
+	if src[p-1] == '/' {
+		return p + 1, p + 1, nil
+	}
+	// Skip trailing whitespaces, comments and processing instructions.
+	end := n
+	for {
+		for end > p && skipTable[src[end-1]] {
+			end--
+		}
+		var open []byte
+		switch {
+		case bytes.HasSuffix(src[:end], bCommentClose):
+			open = bCommentOpen
+		case bytes.HasSuffix(src[:end], bPIClose):
+			open = bPIStart
+		}
+		if open == nil {
+			break
+		}
+		if end = bytes.LastIndex(src[:end], open); end <= p {
+			return offset, offset, ErrUnclosedTag
+		}
+	}
+	// Check name of the close tag.
+	c1 := bytes.LastIndex(src[:end], bCTag)
+	if c1 <= p || src[end-1] != '>' {
+		return offset, offset, ErrUnclosedTag
+	}
+	name := bytealg.TrimRight(src[c1+len(bCTag):end-1], bFmt)
+	if !bytes.HasPrefix(src[offset+1:], name) || isNameChar(src[offset+1+len(name)]) {
+		return offset, offset, ErrUnclosedTag
+	}
+	return p + 1, c1, nil
+}
+
+// Split content [c0, c1) to chunks at least size bytes at start tags of the top level elements.
+//
+// Returns bounds of chunks: c0, start offsets of chunks and c1.
+func splitContent(src []byte, c0, c1, size int, bounds []int) []int {
+	bounds = append(bounds[:0], c0)
+	src = src[:c1]
+	depth, next := 0, c0+size
+	for i := c0; i < c1; {
+		j := vector.IndexByteAt(src, '<', i)
+		if j == -1 {
+			break
+		}
+		var end []byte
+		switch {
+		case bytes.HasPrefix(src[j:], bCommentOpen):
+			end = bCommentClose
+		case bytes.HasPrefix(src[j:], bCDATAOpen):
+			end = bCDATAClose
+		case bytes.HasPrefix(src[j:], bPIStart):
+			end = bPIClose
+		case bytes.HasPrefix(src[j:], bCTag):
+			depth--
+			end = btTagC
+		case j+1 < c1 && src[j+1] == '!':
+			end = btTagC
+		default:
+			if depth == 0 && j >= next {
+				bounds = append(bounds, j)
+				next = j + size
+			}
+			p := tagEnd(src, c1, j)
+			if p == -1 {
+				i = c1
+				continue
+			}
+			if src[p-1] != '/' {
+				depth++
+			}
+			i = p + 1
+			continue
+		}
+		// Malformed tail is left to the chunk parser.
+		if p := bytealg.IndexAtBytes(src, end, j+1); p != -1 {
+			i = p + len(end)
+		} else {
+			i = c1
+		}
+	}
+	return append(bounds, c1)
+}
+
+// Find '>' closing the tag started at offset. Quoted attribute values are skipped.
+func tagEnd(src []byte, n, offset int) int {
+	var q byte
+	for i := offset; i < n; i++ {
+		switch c := src[i]; {
+		case q != 0:
+			if c == q {
+				q = 0
+			}
+		case c == '"' || c == '\'':
+			q = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,184 @@
+package xmlvector
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func parallelSrc(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<!-- <item> in comment -->\n<catalog region=\"eu\">\n")
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			_, _ = fmt.Fprintf(&buf, "\t<item id=\"%d\" note=\"a > b\"><title>Item %d</title></item>\n", i, i)
+		case 1:
+			_, _ = fmt.Fprintf(&buf, "\t<item id=\"%d\"><![CDATA[<item> & </item>]]></item>\n\t<!-- </catalog> -->\n", i)
+		default:
+			_, _ = fmt.Fprintf(&buf, "\t<item id=\"%d\"/>\n", i)
+		}
+	}
+	buf.WriteString("</catalog>\n")
+	return buf.Bytes()
+}
+
+func TestParallel(t *testing.T) {
+	src := parallelSrc(1000)
+	p := NewParallel(4).SetChunkSize(1024)
+	t.Run("records", func(t *testing.T) {
+		if err := p.Parse(src); err != nil {
+			t.Fatal(err, p.ErrorOffset())
+		}
+		if len(p.bounds) < 10 {
+			t.Error("source isn't split, chunks", len(p.bounds)-1)
+		}
+		if v := p.Root().Dot("@region").String(); v != "eu" {
+			t.Error("root attribute mismatch, got", v)
+		}
+		var cnt int
+		for i, rec := range p.Records() {
+			if id := rec.Dot("@id").String(); id != fmt.Sprint(i) {
+				t.Fatalf("record #%d id mismatch, got %s", i, id)
+			}
+			cnt++
+		}
+		if cnt != 1000 {
+			t.Error("records count mismatch, got", cnt)
+		}
+	})
+	t.Run("lookup", func(t *testing.T) {
+		if err := p.Parse(src); err != nil {
+			t.Fatal(err)
+		}
+		if p.Len() != 1000 || p.Record(1000) != nil || p.Record(-1) != nil {
+			t.Error("records count mismatch, got", p.Len())
+		}
+		if id := p.Record(700).Dot("@id").String(); id != "700" {
+			t.Error("record id mismatch, got", id)
+		}
+		for path, expect := range map[string]string{
+			"@version":              "1.0",
+			"catalog@region":        "eu",
+			"catalog.item@id":       "0",
+			"catalog.3.title":       "Item 3",
+			"catalog.999@id":        "999",
+			"catalog.700":           "<item> & </item>",
+			"catalog.1000@id":       "",
+			"catalog.record@id":     "",
+			"shop.0@id":             "",
+			"catalog.0.title.x":     "",
+			"catalog.0@note":        "a > b",
+			"catalog.item.title":    "Item 0",
+			"catalog.2.title":       "",
+			"catalog.999.title":     "Item 999",
+			"catalog.0.nonexistent": "",
+		} {
+			if s := p.Dot(path).String(); s != expect {
+				t.Errorf("%s mismatch, need %q got %q", path, expect, s)
+			}
+		}
+		if s := p.Get("catalog", "5", "@id").String(); s != "5" {
+			t.Error("get mismatch, got", s)
+		}
+	})
+	t.Run("document", func(t *testing.T) {
+		vec := NewVector()
+		_ = vec.ParseCopy(src)
+		var b0, b1 bytes.Buffer
+		_ = vec.Marshal(&b0)
+		_ = p.Marshal(&b1)
+		if b0.String() != b1.String() {
+			t.Error("document mismatch")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		bad := bytes.Replace(src, []byte(`<item id="700"`), []byte(`<item id="700" <`), 1)
+		vec := NewVector()
+		err0 := vec.ParseCopy(bad)
+		if err := p.Parse(bad); err == nil || err != err0 || p.ErrorOffset() != vec.ErrorOffset() {
+			t.Errorf("error mismatch, need %v at %d got %v at %d", err0, vec.ErrorOffset(), err, p.ErrorOffset())
+		}
+	})
+	t.Run("trailing", func(t *testing.T) {
+		doc := `<catalog><item id="0"/><item id="1"/></catalog><!-- </end> -->` + "\n"
+		if err := p.ParseString(doc); err != nil {
+			t.Fatal(err, p.ErrorOffset())
+		}
+		var cnt int
+		for range p.Records() {
+			cnt++
+		}
+		if cnt != 2 {
+			t.Error("records count mismatch, got", cnt)
+		}
+		for range Children(p.Root()) {
+			t.Error("root must have no children")
+		}
+		if err := p.ParseString(`<catalog><item/></item>`); err != ErrUnclosedTag {
+			t.Error("error mismatch, got", err)
+		}
+	})
+	t.Run("prolog", func(t *testing.T) {
+		doc := `<catalog><item id="0"/><item id="1"/></catalog>`
+		if err := p.ParseString(doc); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_ = p.Marshal(&buf)
+		if buf.String() != `<catalog><item id="0"></item><item id="1"></item></catalog>` {
+			t.Error("marshal mismatch, got", buf.String())
+		}
+		if s := p.Dot("catalog.1@id").String(); s != "1" {
+			t.Error("record id mismatch, got", s)
+		}
+	})
+	t.Run("write", func(t *testing.T) {
+		if err := p.Parse(src); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Marshal(&failWriter{n: 1000}); err != errWrite {
+			t.Error("error mismatch, got", err)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		if err := p.ParseString(`<catalog a="1"/>`); err != nil {
+			t.Fatal(err)
+		}
+		for range p.Records() {
+			t.Error("unexpected record")
+		}
+		assertStr(t, p.Head(), "catalog@a", "1", vector.TypeAttribute)
+	})
+	t.Run("setup", func(t *testing.T) {
+		p.SetSetup(func(vec *Vector) { vec.SetLimits(Limits{MaxDepth: 1}) })
+		defer p.SetSetup(nil)
+		if err := p.ParseString(strings.Repeat(" ", 10) + string(src)); err != ErrMaxDepth {
+			t.Errorf("error mismatch, need %v got %v", ErrMaxDepth, err)
+		}
+	})
+}
+
+func BenchmarkParallel(b *testing.B) {
+	src := parallelSrc(1e5)
+	b.Run("vector", func(b *testing.B) {
+		vec := NewVector()
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			vec.Reset()
+			_ = vec.Parse(src)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		p := NewParallel(0)
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = p.Parse(src)
+		}
+	})
+}
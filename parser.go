@@ -3,6 +3,7 @@ package xmlvector
 import (
 	"bytes"
 	"errors"
+	"unicode/utf8"
 
 	"github.com/koykov/bytealg"
 	"github.com/koykov/vector"
@@ -23,10 +24,7 @@ var (
 	bPrologOpen  = []byte("<?xml")
 	bPrologClose = []byte("?>")
 
-	bDocType  = []byte("<!DOCTYPE")
-	bDTElem   = []byte("<!ELEMENT")
-	bDTPCDATA = []byte("#PCDATA")
-	bDTClose  = []byte("]>")
+	bDocType = []byte("<!DOCTYPE")
 
 	bPIOpen  = []byte("<?xml-stylesheet")
 	bPIClose = []byte("?>")
@@ -47,31 +45,79 @@ var (
 )
 
 // Main internal parser helper.
-func (vec *Vector) parse(s []byte, copy bool) (err error) {
+//
+// frag enables fragment mode, see ParseFragment.
+func (vec *Vector) parse(s []byte, copy, frag bool) (err error) {
 	if !vec.CheckBit(vector.FlagInit) {
 		err = errBadInit
 		return
 	}
 
-	s = bytealg.TrimBytesFmt4(s)
-	if err = vec.SetSrc(s, copy); err != nil {
+	var decoded bool
+	orig := len(s)
+	if s, decoded, err = vec.decode(s); err != nil {
 		return
 	}
+	if decoded {
+		copy = false
+	} else if vec.html && !copy && !vec.ownSrc(s) {
+		// Names are lowercased in place, so source of the caller (possibly read-only string memory) is copied.
+		copy = true
+	}
+
+	if frag && len(bytealg.TrimLeft(s, bFmt)) == 0 {
+		return vec.emptyFragment()
+	}
+	if len(bytealg.TrimLeft(s, bFmt)) == 0 {
+		err = vector.ErrEmptySrc
+		return
+	}
+	t := bytealg.TrimBytesFmt4(s)
+	if vec.trackPos {
+		// Byte order mark stripped from untranscoded source is counted by offsets.
+		var bom int
+		if !decoded {
+			bom = orig - len(s)
+		}
+		vec.initPos(bom, s[:cap(s)-cap(t)], t)
+	}
+	if err = vec.SetSrc(t, copy); err != nil {
+		return
+	}
+	vec.arrPath = vec.arrPath[:0]
+	vec.diags = vec.diags[:0]
 
 	offset := 0
 	// Create root node and register it.
-	root, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	_, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	vec.setPos(i, 0, vec.SrcLen())
 
 	// Parse source data.
-	offset, err = vec.parseGeneric(0, offset, root)
+	if frag {
+		offset, err = vec.parseFragment(offset, i)
+	} else {
+		offset, err = vec.parseGeneric(0, offset, i)
+	}
 	if err != nil {
 		vec.SetErrOffset(offset)
 		return err
 	}
-	vec.ReleaseNode(i, root)
+	if decoded {
+		vec.fixEncoding()
+	}
+	if vec.multiDoc && !frag {
+		if offset, err = vec.parseDocs(offset); err != nil {
+			vec.SetErrOffset(offset)
+			return err
+		}
+	}
 
 	// Check unparsed tail.
 	if offset < vec.SrcLen() {
+		if vec.recover {
+			vec.diag(offset, vector.ErrUnparsedTail)
+			return
+		}
 		vec.SetErrOffset(offset)
 		return vector.ErrUnparsedTail
 	}
@@ -80,25 +126,35 @@ func (vec *Vector) parse(s []byte, copy bool) (err error) {
 }
 
 // Generic parser helper.
-func (vec *Vector) parseGeneric(depth, offset int, node *vector.Node) (int, error) {
+//
+// Nodes array may grow during parsing, so the root node is addressed by index ri.
+func (vec *Vector) parseGeneric(depth, offset, ri int) (int, error) {
 	var (
 		err error
 		eof bool
 		cn  *vector.Node
-		cni int
 	)
+	node := vec.NodeAt(ri)
 	node.SetOffset(vec.Index.Len(depth))
-	if offset, err = vec.parseProlog(depth+1, offset, node); err != nil {
+	offset, err = vec.parseProlog(depth+1, offset, node)
+	// Prolog attributes may invalidate the pointer, so the node is released by index.
+	vec.ReleaseNode(ri, node)
+	if err != nil {
 		return offset, err
 	}
 	if offset, eof = vec.skipHeader(offset); eof {
 		return offset, vector.ErrUnexpEOF
 	}
-	if cn, cni, offset, err = vec.parseElement(depth+1, offset, node); err != nil {
-		return offset, err
+	if cn, _, offset, err = vec.parseElement(depth+1, offset, ri); err != nil {
+		if !vec.recover || cn == nil || isFatal(err) {
+			return offset, err
+		}
+		// Keep partially parsed root element.
+		vec.diag(offset, err)
+		offset = vec.SrcLen()
 	}
 	if cn != nil {
-		vec.ReleaseNode(cni, cn)
+		vec.closeNode(cn)
 	}
 	return offset, nil
 }
@@ -110,28 +166,34 @@ func (vec *Vector) parseProlog(depth, offset int, node *vector.Node) (int, error
 		eof bool
 	)
 	node.SetOffset(vec.Index.Len(depth))
-	src := vec.Src()[offset:]
+	src := vec.Src()
 	n := len(src)
 	_ = src[n-1]
-	if len(src) > 4 && bytes.Equal(src[:5], bPrologOpen) {
-		offset = 5
-		offset, _, err = vec.parseAttr(depth, offset, node)
+	if hasProlog(src[offset:]) {
+		// Attributes parser consumes closing "?>" as well.
+		if offset, _, err = vec.parseAttr(depth, offset+len(bPrologOpen), node); err != nil {
+			return offset, err
+		}
 	} else {
 		attr, i := vec.AcquireChildWithType(node, depth, vector.TypeAttribute)
+		vec.setParent(i, node.Index())
 		attr.Key().Init(bPairs, offsetVersionKey, lenVersionKey)
 		attr.Value().Init(bPairs, offsetVersionVal, lenVersionVal)
 		vec.ReleaseNode(i, attr)
 		return offset, nil
 	}
-	if n-offset >= 2 && bytes.Equal(src[:offset+2], bPrologClose) {
-		offset += 2
-	}
 	if offset, eof = skipCommentAndFmt(src, n, offset); eof {
 		err = vector.ErrUnexpEOF
 	}
 	return offset, err
 }
 
+// Check if src starts with XML declaration.
+func hasProlog(src []byte) bool {
+	p := len(bPrologOpen)
+	return p < len(src) && bytes.HasPrefix(src, bPrologOpen) && (skipTable[src[p]] || src[p] == '?')
+}
+
 // Skip header part (doctype and processing instructions)
 // PI == processing instructions
 // eg: <?xml-stylesheet type="text/css" href="my-style.css"?>
@@ -146,15 +208,16 @@ loop:
 	if len(srcc) < lenDTOpen {
 		return offset, false
 	}
-	if dt = bytes.Equal(srcc[:lenDTOpen], bDocType); dt {
-		// Check local DT.
-		p0, p1, p2 := bytealg.IndexAtBytes(srcc, bDTElem, lenDTOpen), bytealg.IndexAtBytes(srcc, bDTPCDATA, lenDTOpen), bytealg.IndexAtBytes(srcc, bDTClose, lenDTOpen)
-		if p0 != -1 && p1 > p0 && p2 > p1 {
-			offset += p2 + 2
-		} else if p := vector.IndexByteAt(srcc, '>', lenDTOpen); p != -1 {
-			// Check DTD file.
-			offset += p + 1
+	if dt = bytes.Equal(srcc[:lenDTOpen], bDocType) || (vec.html && bytes.EqualFold(srcc[:lenDTOpen], bDocType)); dt {
+		end, sub0, sub1 := dtdBounds(srcc)
+		if end == -1 {
+			return offset, true
 		}
+		if sub0 != -1 {
+			// Check local DT.
+			vec.parseDTD(srcc[sub0:sub1])
+		}
+		offset += end
 	}
 	// PI
 	if len(srcc) < lenPIOpen {
@@ -176,12 +239,73 @@ loop:
 	return offset, false
 }
 
+// States of the element being parsed, see elemFrame.
+const (
+	// Start tag is parsed, content isn't yet.
+	elemContent = iota
+	// Child elements are being parsed.
+	elemChildren
+	// Content is parsed, close tag expected.
+	elemClose
+)
+
+// Open element in the stack of the element parser.
+type elemFrame struct {
+	// Index and depth of the node, offset of the start tag and bounds of the name.
+	idx, depth, start int
+	tag0, tag1        int
+	state             uint8
+	// Siblings homogeneity state: key of the first child, count of children and mixed names flag.
+	pk    vector.Byteptr
+	cnt   int
+	mixed bool
+	// Length of array path before the element, see pushArrPath.
+	plen int
+}
+
 // Try parse XML element.
-func (vec *Vector) parseElement(depth, offset int, root *vector.Node) (*vector.Node, int, int, error) {
+//
+// Parser is iterative: open elements keep in the stack, so nesting depth is limited only by Limits.MaxDepth.
+//
+// Element becomes a child of the node with index pi. Nodes are addressed by indices, since pointers are invalidated by
+// growth of nodes array.
+func (vec *Vector) parseElement(depth, offset, pi int) (*vector.Node, int, int, error) {
+	var (
+		i    int
+		err  error
+		done bool
+	)
+	vec.estack = vec.estack[:0]
+	if i, offset, done, err = vec.openElem(pi, depth, offset); i == -1 {
+		return nil, i, offset, err
+	}
+	if err != nil || done {
+		return vec.NodeAt(i), i, offset, err
+	}
+	for len(vec.estack) > 0 {
+		switch vec.estack[len(vec.estack)-1].state {
+		case elemContent:
+			offset, err = vec.parseContent(offset)
+		case elemChildren:
+			offset, err = vec.parseChildren(offset)
+		case elemClose:
+			offset, err = vec.closeElem(offset)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return vec.NodeAt(i), i, offset, err
+}
+
+// Parse start tag of the element and push it to the stack.
+//
+// Returns index of the element node (-1 if node wasn't created) and true if the element is complete (empty, void, etc)
+// and was popped back.
+func (vec *Vector) openElem(pi, depth, offset int) (int, int, bool, error) {
 	var (
 		err error
 		p   int
-		tag []byte
 
 		eof, clp bool
 	)
@@ -190,160 +314,350 @@ func (vec *Vector) parseElement(depth, offset int, root *vector.Node) (*vector.N
 	n := len(src)
 	_ = src[n-1]
 	if src[offset] != '<' {
-		return nil, -1, offset, ErrNoRoot
+		return -1, offset, true, ErrNoRoot
 	}
+	if l := vec.limits.MaxDepth; l > 0 && depth > l {
+		return -1, offset, true, ErrMaxDepth
+	}
+	if err = vec.checkCtx(); err != nil {
+		return -1, offset, true, err
+	}
+	start := offset
 	offset++
 	if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 {
-		return nil, -1, offset, vector.ErrUnexpEOF
+		return -1, offset, true, vector.ErrUnexpEOF
 	}
 	if p = bytealg.IndexAnyAtBytes(src, bAfterTag, offset); p == -1 {
-		return nil, -1, offset, ErrUnclosedTag
+		return -1, offset, true, ErrUnclosedTag
 	}
 	p = skipNameTable(src, n, offset, p)
+	if l := vec.limits.MaxNameLen; l > 0 && p-offset > l {
+		return -1, offset, true, ErrLongName
+	}
 
-	node, i := vec.AcquireChildWithType(root, depth, vector.TypeObject)
+	node, i, err := vec.acquireChild(pi, depth, vector.TypeObject)
+	if err != nil {
+		return -1, offset, true, err
+	}
 	node.SetOffset(vec.Index.Len(depth + 1))
 	node.Key().InitRaw(srcp, offset, p-offset)
-
-	tag = src[offset:p]
-	offset = p
-
-	if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 {
-		return node, i, offset, vector.ErrUnexpEOF
+	tag := src[offset:p]
+	if vec.html {
+		toLower(tag)
 	}
-	if c := src[offset]; c != '/' && c != '>' {
-		if offset, clp, err = vec.parseAttr(depth+1, offset, node); err != nil {
-			return node, i, offset, err
-		}
-
-		if clp {
-			return node, i, offset, nil
-		}
+	vec.estack = append(vec.estack, elemFrame{idx: i, depth: depth, start: start, tag0: offset, tag1: p})
+	offset = p
 
-		if offset, err = vec.parseContent(depth, offset, node); err != nil {
-			return node, i, offset, err
-		}
-		if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 {
-			return node, i, offset, vector.ErrUnexpEOF
-		}
-		if offset, err = skipCTag(src, n, offset, tag); err != nil {
-			return node, i, offset, err
-		}
-		if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 {
-			return node, i, offset, vector.ErrUnexpEOF
-		}
-		return node, i, offset, nil
+	if offset, eof = skipCommentAndFmt(src, n, offset); eof {
+		return i, offset, false, vector.ErrUnexpEOF
 	}
-	if src[offset] == '/' {
+	switch src[offset] {
+	case '/':
 		if offset < n-1 && src[offset+1] == '>' {
-			offset += 2
-			return node, i, offset, nil
-		} else {
-			return node, i, offset, ErrUnclosedTag
+			return i, vec.popElem(offset + 2), true, nil
 		}
-	}
-	if src[offset] == '>' {
+		return i, offset, false, ErrUnclosedTag
+	case '>':
 		offset++
-		if offset, err = vec.parseContent(depth, offset, node); err != nil {
-			return node, i, offset, err
+	default:
+		offset, clp, err = vec.parseAttr(depth+1, offset, node)
+		// Attributes may grow nodes array, so the node is released by index.
+		vec.ReleaseNode(i, node)
+		if err != nil {
+			if !vec.recover || isFatal(err) {
+				return i, offset, false, err
+			}
+			offset, clp = vec.recoverAttr(offset, err)
 		}
-		if offset, err = skipCTag(src, n, offset, tag); err != nil {
-			return node, i, offset, err
+		if clp {
+			return i, vec.popElem(offset), true, nil
 		}
-		if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 {
-			return node, i, offset, vector.ErrUnexpEOF
+		node = vec.NodeAt(i)
+	}
+	if vec.html {
+		var ok bool
+		if offset, ok, err = vec.parseHTMLContent(offset, node, tag); ok {
+			if err != nil {
+				return i, offset, false, err
+			}
+			return i, vec.popElem(offset), true, nil
 		}
-		return node, i, offset, nil
 	}
-	return node, i, offset, ErrUnclosedTag
+	return i, offset, false, nil
+}
+
+// Pop complete element from the stack and register its position.
+func (vec *Vector) popElem(offset int) int {
+	l := len(vec.estack) - 1
+	f := &vec.estack[l]
+	vec.setPos(f.idx, f.start, offset)
+	vec.estack = vec.estack[:l]
+	return offset
+}
+
+// Acquire child node of the parent with index pi.
+//
+// Parent's limit is set by index since pointers to nodes may be invalidated by growth of nodes array.
+func (vec *Vector) acquireChild(pi, depth int, typ vector.Type) (*vector.Node, int, error) {
+	if l := vec.limits.MaxNodes; l > 0 && vec.Len() >= l {
+		return nil, -1, ErrMaxNodes
+	}
+	node, i := vec.AcquireNodeWithType(depth, typ)
+	vec.NodeAt(pi).SetLimit(vec.Index.Len(depth))
+	vec.setParent(i, pi)
+	return node, i, nil
 }
 
-// Try parse XML element content.
-func (vec *Vector) parseContent(depth, offset int, root *vector.Node) (int, error) {
+// Try parse content of the top element: text, CDATA section or the start of child elements.
+func (vec *Vector) parseContent(offset int) (int, error) {
 	var (
 		p     int
 		eof   bool
 		cdata bool
-		err   error
 	)
 	src := vec.Src()
 	srcp := vec.SrcAddr()
 	n := len(src)
 	_ = src[n-1]
+	f := &vec.estack[len(vec.estack)-1]
 	if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-		return offset, vector.ErrUnexpEOF
+		if !vec.recover {
+			return vec.failElem(offset, vector.ErrUnexpEOF)
+		}
+		// Close tags of all open elements will be implied, see recoverCTag.
+		f.state = elemClose
+		return offset, nil
 	}
 	offset, cdata = skipCDATA(src, n, offset)
 
-	if src[offset] == '<' && !cdata {
-		sl := n
-		var (
-			pn, cn *vector.Node
-			cni    int
-			arr    bool
-		)
-		for {
-			if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-				return offset, vector.ErrUnexpEOF
-			}
-			if cn, cni, offset, err = vec.parseElement(depth+1, offset, root); err != nil {
-				return offset, err
-			}
-			if cn != nil {
-				vec.ReleaseNode(cni, cn)
-			}
-			if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-				return offset, vector.ErrUnexpEOF
-			}
-			if !arr {
-				if pn == nil && cn != nil {
-					pn = cn
-				} else if cn != nil && cn.KeyString() == pn.KeyString() {
-					arr = true
-				}
-			}
-			if sl == offset {
-				break
-			}
-			if offset+1 < sl {
-				if bytes.Equal(src[offset:offset+2], bCTag) {
-					break
-				}
-			}
+	node := vec.NodeAt(f.idx)
+	elems := src[offset] == '<' && !cdata
+	if vec.html && !elems && !cdata {
+		// Text followed by child element is a mixed content, so the text is skipped.
+		if p = vector.IndexByteAt(src, '<', offset); p != -1 && p+1 < n && src[p+1] != '/' && src[p+1] != '!' &&
+			!vec.impliedEnd(node, src, n, p) {
+			elems, offset = true, p
 		}
-		if arr {
-			root.SetType(vector.TypeArray)
-			*root.Value() = *pn.Key() // Use value as an alias for arrays.
-			root.Value().SetBit(flagAlias, true)
+	}
+	if elems {
+		if vec.arrRules() {
+			f.plen = vec.pushArrPath(node)
 		}
+		f.state = elemChildren
+		return offset, nil
+	}
+
+	var d int
+	if cdata {
+		if p = bytealg.IndexAtBytes(src, bCDATAClose, offset); p == -1 {
+			if !vec.recover {
+				return vec.failElem(offset, vector.ErrUnexpEOF)
+			}
+			// Truncated CDATA section.
+			vec.diag(offset, vector.ErrUnexpEOF)
+			p, d = n, -3
+		}
+		d += 3
 	} else {
-		var d int
-		if cdata {
-			if p = bytealg.IndexAtBytes(src, bCDATAClose, offset); p == -1 {
-				return offset, vector.ErrUnexpEOF
+		if p = vector.IndexByteAt(src, '<', offset); p == -1 {
+			if !vec.recover {
+				return vec.failElem(offset, ErrUnclosedTag)
 			}
-			d = 3
-		} else {
-			if p = vector.IndexByteAt(src, '<', offset); p == -1 {
-				return offset, ErrUnclosedTag
+			// Truncated text.
+			p = n
+		}
+	}
+	p1 := p
+	if vec.html && !cdata {
+		p1 = vec.trimImplied(node, src, n, offset, p)
+	}
+	if l := vec.limits.MaxTextLen; l > 0 && p1-offset > l {
+		return vec.failElem(offset, ErrLongText)
+	}
+	raw := src[offset:p1]
+	esc := !cdata && vec.checkEscape(raw)
+	if esc && vec.strictRefs {
+		if off, err := vec.checkRefs(raw); err != nil {
+			if !vec.recover {
+				return vec.failElem(offset+off, err)
 			}
+			vec.diag(offset+off, err)
+		}
+	}
+	node.Value().InitRaw(srcp, offset, p1-offset)
+	node.Value().SetBit(flagEscape, esc)
+	node.Value().SetBit(flagCDATA, cdata)
+	node.Value().SetBit(flagNormEOL, bytes.IndexByte(raw, '\r') != -1)
+	if !node.Key().CheckBit(flagAttr) {
+		node.SetType(vector.TypeString)
+	}
+	f.state = elemClose
+	return p + d, nil
+}
+
+// Try parse the next child element of the top element.
+func (vec *Vector) parseChildren(offset int) (int, error) {
+	var eof bool
+	src := vec.Src()
+	n := len(src)
+	fi := len(vec.estack) - 1
+	f := &vec.estack[fi]
+	for {
+		if offset, eof = skipCommentAndFmt(src, n, offset); eof || !vec.html {
+			break
+		}
+		p := offset
+		if offset, eof = skipHTMLText(src, n, offset); eof || offset == p {
+			break
+		}
+	}
+	if eof {
+		if !vec.recover {
+			return vec.failElem(offset, vector.ErrUnexpEOF)
+		}
+		vec.endChildren(fi)
+		return offset, nil
+	}
+	if vec.recover {
+		if offset = vec.skipStray(src, n, offset); offset == n {
+			vec.endChildren(fi)
+			return offset, nil
 		}
-		raw := src[offset:p]
-		root.Value().InitRaw(srcp, offset, p-offset)
-		root.Value().SetBit(flagEscape, vec.checkEscape(raw))
-		if !root.Key().CheckBit(flagAttr) {
-			root.SetType(vector.TypeString)
+	}
+	if bytes.HasPrefix(src[offset:], bCTag) || (vec.html && vec.impliedEnd(vec.NodeAt(f.idx), src, n, offset)) {
+		// Close tag of the element (or the implied one).
+		vec.endChildren(fi)
+		return offset, nil
+	}
+
+	ci, offset, done, err := vec.openElem(f.idx, f.depth+1, offset)
+	switch {
+	case err != nil && ci != -1:
+		// Child element is in the stack.
+		return vec.failElem(offset, err)
+	case err != nil:
+		return vec.recoverChild(fi, -1, offset, err)
+	case done:
+		vec.childDone(fi, ci)
+	}
+	return offset, nil
+}
+
+// Finish children of the element fi and detect array.
+func (vec *Vector) endChildren(fi int) {
+	f := &vec.estack[fi]
+	// Only homogeneous repeated siblings makes an array, mixed siblings are available using Group().
+	arr := f.cnt > 1 && !f.mixed
+	if vec.arrRules() {
+		if f.cnt > 0 {
+			arr = vec.checkArrRules(f.pk.RawString(), arr, !f.mixed)
 		}
-		offset = p + d
+		vec.arrPath = vec.arrPath[:f.plen]
+	}
+	if arr {
+		node := vec.NodeAt(f.idx)
+		node.SetType(vector.TypeArray)
+		*node.Value() = f.pk // Use value as an alias for arrays.
+		node.Value().SetBit(flagAlias, true)
+	}
+	f.state = elemClose
+}
+
+// Register complete child ci of the element fi.
+func (vec *Vector) childDone(fi, ci int) {
+	f := &vec.estack[fi]
+	cn := vec.NodeAt(ci)
+	vec.closeNode(cn)
+	// Check siblings homogeneity.
+	if f.cnt == 0 {
+		f.pk = *cn.Key()
+	} else if !f.mixed && !bytes.Equal(cn.Key().RawBytes(), f.pk.RawBytes()) {
+		f.mixed = true
+	}
+	f.cnt++
+}
+
+// Skip close tag of the top element and pop it.
+func (vec *Vector) closeElem(offset int) (int, error) {
+	var (
+		eof bool
+		err error
+	)
+	src := vec.Src()
+	n := len(src)
+	fi := len(vec.estack) - 1
+	f := &vec.estack[fi]
+	if offset, eof = skipCommentAndFmt(src, n, offset); eof && !vec.recover {
+		return vec.failElem(offset, vector.ErrUnexpEOF)
+	}
+	if offset, err = vec.skipCTag(src, n, offset, src[f.tag0:f.tag1]); err != nil {
+		return vec.failElem(offset, err)
+	}
+	idx, depth := f.idx, f.depth
+	offset = vec.popElem(offset)
+	if offset, eof = skipCommentAndFmt(src, n, offset); eof && depth > 1 && !vec.recover {
+		return offset, vector.ErrUnexpEOF
+	}
+	if fi > 0 {
+		vec.childDone(fi-1, idx)
 	}
 	return offset, nil
 }
 
+// Handle error of the top element: pop it and let the parent recover.
+func (vec *Vector) failElem(offset int, err error) (int, error) {
+	fi := len(vec.estack) - 1
+	f := &vec.estack[fi]
+	if f.state == elemChildren && vec.arrRules() {
+		vec.arrPath = vec.arrPath[:f.plen]
+	}
+	ci := f.idx
+	vec.estack = vec.estack[:fi]
+	return vec.recoverChild(fi-1, ci, offset, err)
+}
+
+// Recover from error of the child ci (-1 if node wasn't created) of the element fi.
+//
+// Error returns as is outside of recovery mode, for the top level element (fi == -1) and for limits violation.
+func (vec *Vector) recoverChild(fi, ci, offset int, err error) (int, error) {
+	if !vec.recover || fi < 0 || isFatal(err) {
+		return offset, err
+	}
+	vec.diag(offset, err)
+	if ci != -1 {
+		vec.childDone(fi, ci)
+	}
+	// Skip the rest of malformed element.
+	var eof bool
+	if offset, eof = vec.resync(vec.Src(), vec.SrcLen(), offset); eof {
+		vec.endChildren(fi)
+	}
+	return offset, nil
+}
+
+// Mark children range of the node as empty if nothing was registered after node's offset.
+//
+// Zero offset and limit points to the first entry of the index row and produces phantom child, so empty range is marked
+// using equal non-zero offset and limit.
+func (vec *Vector) closeNode(node *vector.Node) {
+	off := node.Offset()
+	if vec.Index.Len(node.Depth()+1) != off {
+		return
+	}
+	if off == 0 {
+		off = 1
+	}
+	node.SetOffset(off).SetLimit(off)
+}
+
 // Try parse XML element attributes.
+//
+// Whitespaces around '=' are allowed. In lenient mode (see SetLenientAttrs) unquoted values and attributes without
+// values are accepted as well.
 func (vec *Vector) parseAttr(depth, offset int, node *vector.Node) (int, bool, error) {
 	var (
-		err      error
-		eof, clp bool
+		eof bool
+		cnt int
 	)
 
 	src := vec.Src()
@@ -353,80 +667,178 @@ func (vec *Vector) parseAttr(depth, offset int, node *vector.Node) (int, bool, e
 
 	for {
 		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-			return offset, clp, vector.ErrUnexpEOF
+			return offset, false, vector.ErrUnexpEOF
 		}
+		switch src[offset] {
+		case '?', '/':
+			if offset+1 == n {
+				return offset + 1, false, vector.ErrUnexpEOF
+			}
+			if src[offset+1] != '>' {
+				return offset + 1, false, ErrUnexpToken
+			}
+			return offset + 2, true, nil
+		case '>':
+			return offset + 1, false, nil
+		}
+
 		posName := offset
-		posName1 := vector.IndexByteAt(src, '=', offset)
-		if posName1 == -1 {
-			err = ErrBadAttr
-			break
+		posName1 := skipAttrName(src, n, offset)
+		if posName1 == posName || (posName1 < n && !isAttrDelim(src[posName1])) {
+			return posName1, false, ErrBadAttr
 		}
-		offset = posName1
-		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-			return offset, clp, vector.ErrUnexpEOF
+		if l := vec.limits.MaxNameLen; l > 0 && posName1-posName > l {
+			return posName, false, ErrLongName
 		}
-		offset++
-		var c byte
-		if c = src[offset]; c != '"' && c != '\'' {
-			err = ErrBadAttr
-			break
+		if cnt++; vec.limits.MaxAttrs > 0 && cnt > vec.limits.MaxAttrs {
+			return posName, false, ErrMaxAttrs
 		}
-		offset++
-		posVal := offset
-		posVal1 := vector.IndexByteAt(src, c, offset)
-		if posVal1 == -1 {
-			err = ErrBadAttr
-			break
+		if vec.html {
+			toLower(src[posName:posName1])
+		}
+		if offset, eof = skipCommentAndFmt(src, n, posName1); eof {
+			return offset, false, vector.ErrUnexpEOF
 		}
 
+		var posVal, posVal1, next int
+		switch c := src[offset]; {
+		case c == '=':
+			if offset, eof = skipCommentAndFmt(src, n, offset+1); eof {
+				return offset, false, vector.ErrUnexpEOF
+			}
+			if c = src[offset]; c == '"' || c == '\'' {
+				posVal = offset + 1
+				if posVal1 = vector.IndexByteAt(src, c, posVal); posVal1 == -1 {
+					return offset, false, ErrBadAttr
+				}
+				next = posVal1 + 1
+			} else if vec.lenientAttrs || vec.html {
+				posVal = offset
+				posVal1 = skipUnquoted(src, n, offset)
+				if posVal1 == posVal {
+					return offset, false, ErrBadAttr
+				}
+				next = posVal1
+			} else {
+				return offset, false, ErrBadAttr
+			}
+		case vec.lenientAttrs || vec.html:
+			// Attribute without value.
+			posVal, posVal1, next = posName1, posName1, posName1
+		default:
+			return offset, false, ErrBadAttr
+		}
+
+		if l := vec.limits.MaxTextLen; l > 0 && posVal1-posVal > l {
+			return posVal, false, ErrLongText
+		}
+		val := src[posVal:posVal1]
+		esc := vec.checkEscape(val)
+		if esc && vec.strictRefs {
+			if off, err := vec.checkRefs(val); err != nil {
+				if !vec.recover {
+					return posVal + off, false, err
+				}
+				vec.diag(posVal+off, err)
+			}
+		}
+		if l := vec.limits.MaxNodes; l > 0 && vec.Len() >= l {
+			return posName, false, ErrMaxNodes
+		}
 		attr, i := vec.AcquireChildWithType(node, depth, vector.TypeAttribute)
+		vec.setParent(i, node.Index())
 		attr.Key().InitRaw(srcp, posName, posName1-posName)
-		val := src[posVal:posVal1]
 		attr.Value().InitRaw(srcp, posVal, posVal1-posVal)
-		attr.Value().SetBit(flagEscape, vec.checkEscape(val))
+		attr.Value().SetBit(flagEscape, esc)
+		attr.Value().SetBit(flagNormAttr, checkNormAttr(val))
+		attr.Value().SetBit(flagNormTok, len(vec.dtdTok) > 0 && vec.isTokAttr(node.Key().RawBytes(), attr.Key().RawBytes()))
 		vec.ReleaseNode(i, attr)
+		vec.setPos(i, posName, next)
 		node.Key().SetBit(flagAttr, true)
 
-		offset = posVal1 + 1
-		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
-			return offset, clp, vector.ErrUnexpEOF
+		offset = next
+		if offset == n {
+			return offset, false, vector.ErrUnexpEOF
 		}
-
-		var brk bool
-		b := src[offset]
-		switch b {
-		case '?', '/':
-			offset++
-			if src[offset] != '>' {
-				return offset, clp, ErrUnexpToken
-			}
-			offset++
-			brk, clp = true, true
-		case '>':
-			offset++
-			brk = true
+		if c := src[offset]; !isAttrDelim(c) && c != '?' {
+			// Attributes must be separated by whitespaces.
+			return offset, false, ErrBadAttr
 		}
-		if brk {
-			break
+	}
+}
+
+// SetLenientAttrs enables lenient attributes mode for HTML-ish input: unquoted values (eg: <td width=100>) and
+// attributes without values (eg: <input disabled>) are accepted. Attribute without value gets empty value.
+//
+// By default such attributes fail parsing with ErrBadAttr.
+func (vec *Vector) SetLenientAttrs(lenient bool) *Vector {
+	vec.lenientAttrs = lenient
+	return vec
+}
+
+// Skip attribute name and return offset of the first byte that isn't a name character.
+func skipAttrName(src []byte, n, offset int) int {
+	if offset < n && !isNameStart(src[offset]) {
+		return offset
+	}
+	for offset < n && isNameChar(src[offset]) {
+		offset++
+	}
+	return offset
+}
+
+// Skip unquoted attribute value. Like in HTML, slash belongs to the value, eg: <a href=/path/>.
+func skipUnquoted(src []byte, n, offset int) int {
+	for offset < n {
+		if c := src[offset]; skipTable[c] || c == '>' || c == '"' || c == '\'' || c == '<' || c == '=' || c == '`' {
+			return offset
 		}
+		offset++
 	}
-	return offset, clp, err
+	return offset
 }
 
-// Check p for escaped entities and glyphs.
-func (vec *Vector) checkEscape(p []byte) bool {
-	if len(p) == 0 {
+// Check if c may follow attribute name or value.
+func isAttrDelim(c byte) bool {
+	return skipTable[c] || c == '=' || c == '>' || c == '/'
+}
+
+// Check if c may start a name (see XML spec, NameStartChar production). Non-ASCII bytes are accepted as is.
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || c >= utf8.RuneSelf
+}
+
+// Check if c is a name character (see XML spec, NameChar production).
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9' || c == '-' || c == '.'
+}
+
+// Check if s is a valid element or attribute name.
+func isName(s string) bool {
+	if len(s) == 0 || !isNameStart(s[0]) {
 		return false
 	}
-	offset := 0
-loop:
-	posAmp, posSC := vector.IndexByteAt(p, '&', offset), vector.IndexByteAt(p, ';', offset)
-	if posAmp == -1 || posSC == -1 {
-		return false
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
 	}
-	if posSC-posAmp >= 2 && posAmp-posSC < 5 {
-		return true
+	return true
+}
+
+// Check p for escaped entities and glyphs.
+//
+// Any ampersand means possible reference, validity of references checks on unescaping (or in strict mode, see
+// SetStrictRefs).
+func (vec *Vector) checkEscape(p []byte) bool {
+	return bytes.IndexByte(p, '&') != -1
+}
+
+// Validate references of p and return offset of the first malformed reference.
+func (vec *Vector) checkRefs(p []byte) (int, error) {
+	var ent *Entities
+	if h, ok := vec.Helper.(Helper); ok {
+		ent = h.ent
 	}
-	offset = posSC
-	goto loop
+	return ValidateRefs(p, ent)
 }
@@ -0,0 +1,140 @@
+package xmlvector
+
+import (
+	"strconv"
+
+	"github.com/koykov/vector"
+)
+
+// Max depth of the node that may be processed without allocations.
+const pathStackSize = 16
+
+var (
+	bXPathSep = []byte("/")
+	bXPathAt  = []byte("/@")
+)
+
+// Parent returns parent node of the given node.
+//
+// Null node returns for root nodes, removed nodes and for nodes that don't belong to the vector.
+func (vec *Vector) Parent(node *vector.Node) *vector.Node {
+	i := node.Index()
+	if node.Depth() == 0 || vec.NodeAt(i) != node || i >= len(vec.parents) {
+		return vec.NodeAt(-1)
+	}
+	return vec.NodeAt(vec.parents[i])
+}
+
+// Record index of the parent of the node with index i. Parent index -1 detaches the node.
+func (vec *Vector) setParent(i, pi int) {
+	for len(vec.parents) <= i {
+		vec.parents = append(vec.parents, -1)
+	}
+	vec.parents[i] = pi
+}
+
+// Path returns dot path of the node. The path may be used to re-find the node using vec.Dot().
+//
+// Children of array nodes are addressed using their position, eg: "CATALOG.2.PRICE". Please note, path of repeated
+// element among mixed siblings points to the first element of the group, use XPath to address such elements.
+func (vec *Vector) Path(node *vector.Node) string {
+	return string(vec.AppendPath(nil, node))
+}
+
+// AppendPath appends dot path of the node to dst. See Path.
+func (vec *Vector) AppendPath(dst []byte, node *vector.Node) []byte {
+	var buf [pathStackSize]*vector.Node
+	stack := vec.ancestors(buf[:0], node)
+	if len(stack) == 0 {
+		return dst
+	}
+	off := len(dst)
+	for i := len(stack) - 2; i >= 0; i-- {
+		n := stack[i]
+		switch {
+		case n.Type() == vector.TypeAttribute:
+			dst = append(dst, '@')
+			dst = append(dst, n.KeyBytes()...)
+			continue
+		case len(dst) > off:
+			dst = append(dst, '.')
+		}
+		if pn := stack[i+1]; pn.Type() == vector.TypeArray {
+			dst = strconv.AppendInt(dst, int64(childPos(pn, n)), 10)
+			continue
+		}
+		dst = append(dst, n.KeyBytes()...)
+	}
+	return dst
+}
+
+// XPath returns XPath of the node, eg: "/CATALOG/CD[3]/PRICE" or "/root/@title".
+//
+// Positional index (1-based) adds only if the element has siblings with the same name.
+func (vec *Vector) XPath(node *vector.Node) string {
+	return string(vec.AppendXPath(nil, node))
+}
+
+// AppendXPath appends XPath of the node to dst. See XPath.
+func (vec *Vector) AppendXPath(dst []byte, node *vector.Node) []byte {
+	var buf [pathStackSize]*vector.Node
+	stack := vec.ancestors(buf[:0], node)
+	if len(stack) == 0 {
+		return dst
+	}
+	if len(stack) == 1 {
+		return append(dst, bXPathSep...)
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		n := stack[i]
+		if n.Type() == vector.TypeAttribute {
+			dst = append(dst, bXPathAt...)
+			dst = append(dst, n.KeyBytes()...)
+			continue
+		}
+		dst = append(dst, bXPathSep...)
+		dst = append(dst, n.KeyBytes()...)
+		var pos, cnt int
+		for _, sibling := range Children(stack[i+1]) {
+			if sibling.KeyString() != n.KeyString() {
+				continue
+			}
+			if cnt++; sibling == n {
+				pos = cnt
+			}
+		}
+		if cnt > 1 {
+			dst = append(dst, '[')
+			dst = strconv.AppendInt(dst, int64(pos), 10)
+			dst = append(dst, ']')
+		}
+	}
+	return dst
+}
+
+// Collect node and its ancestors up to the root node (inclusive) to dst.
+//
+// Returns empty list if the node doesn't belong to the vector.
+func (vec *Vector) ancestors(dst []*vector.Node, node *vector.Node) []*vector.Node {
+	if vec.NodeAt(node.Index()) != node {
+		return dst
+	}
+	for node.Type() != vector.TypeNull {
+		dst = append(dst, node)
+		node = vec.Parent(node)
+	}
+	if len(dst) > 0 && dst[len(dst)-1].Depth() != 0 {
+		return dst[:0]
+	}
+	return dst
+}
+
+// Get position of the child in the list of parent's children. Returns -1 if node isn't a child of parent.
+func childPos(parent, node *vector.Node) int {
+	for pos, i := range parent.ChildrenIndices() {
+		if i == node.Index() {
+			return pos
+		}
+	}
+	return -1
+}
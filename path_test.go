@@ -0,0 +1,75 @@
+package xmlvector
+
+import "testing"
+
+func TestPath(t *testing.T) {
+	vec := NewVector()
+	t.Run("root/array", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		node := vec.Dot("CATALOG.2.PRICE")
+		if p := vec.Path(node); p != "CATALOG.2.PRICE" {
+			t.Error("path mismatch, got", p)
+		}
+		if p := vec.XPath(node); p != "/CATALOG/CD[3]/PRICE" {
+			t.Error("xpath mismatch, got", p)
+		}
+		if p := vec.XPath(vec.Dot("CATALOG")); p != "/CATALOG" {
+			t.Error("xpath mismatch, got", p)
+		}
+		for node := range Descendants(vec.Root()) {
+			if vec.Dot(vec.Path(node)) != node {
+				t.Error("can't re-find node by path", vec.Path(node))
+			}
+		}
+	})
+	t.Run("root/attr", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		attr := vec.Dot("root@arg0")
+		if p := vec.Path(attr); p != "root@arg0" {
+			t.Error("path mismatch, got", p)
+		}
+		if p := vec.XPath(attr); p != "/root/@arg0" {
+			t.Error("xpath mismatch, got", p)
+		}
+		if p := vec.Path(vec.Dot("@version")); p != "@version" {
+			t.Error("path mismatch, got", p)
+		}
+		if vec.Parent(vec.Dot("root")) != vec.Root() {
+			t.Error("parent mismatch")
+		}
+		if p := vec.XPath(vec.Root()); p != "/" {
+			t.Error("xpath mismatch, got", p)
+		}
+	})
+	t.Run("parent", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseString(`<r><a x="1"><b/></a><c/></r>`); err != nil {
+			t.Fatal(err)
+		}
+		if vec.Parent(vec.Dot("r.c")) != vec.Dot("r") || vec.Parent(vec.Dot("r.a.b")) != vec.Dot("r.a") || vec.Parent(vec.Dot("r.a@x")) != vec.Dot("r.a") {
+			t.Error("parent mismatch")
+		}
+		// Mutations relocate children ranges.
+		d := vec.Node(vec.Dot("r")).InsertChild(0, "d")
+		if vec.Parent(d.Raw()) != vec.Dot("r") || vec.Parent(vec.Dot("r.c")) != vec.Dot("r") {
+			t.Error("parent mismatch after insert")
+		}
+		c := vec.Dot("r.c")
+		vec.Node(c).Remove()
+		if vec.Parent(c) != vec.NodeAt(-1) {
+			t.Error("removed node must have no parent")
+		}
+	})
+}
+
+func BenchmarkPath(b *testing.B) {
+	b.Run("root/array", func(b *testing.B) {
+		var buf []byte
+		bench(b, func(vec *Vector) {
+			node := vec.Dot("CATALOG.25.YEAR")
+			if buf = vec.AppendXPath(buf[:0], node); string(buf) != "/CATALOG/CD[26]/YEAR" {
+				b.Error("xpath mismatch, got", string(buf))
+			}
+		})
+	})
+}
@@ -9,6 +9,8 @@ import (
 // Pool represents JSON vectors pool.
 type Pool struct {
 	p sync.Pool
+	// Limits applies to every vector taken from the pool, see Vector.SetLimits.
+	limits Limits
 }
 
 var (
@@ -23,15 +25,26 @@ func (p *Pool) Get() *Vector {
 	if v != nil {
 		if vec, ok := v.(*Vector); ok {
 			vec.Helper = helper
-			return vec
+			return vec.SetLimits(p.limits)
 		}
 	}
-	return NewVector()
+	return NewVector().SetLimits(p.limits)
+}
+
+// SetLimits sets restrictions of the sources parsed by vectors of the pool.
+//
+// Must be called before the pool usage.
+func (p *Pool) SetLimits(limits Limits) *Pool {
+	p.limits = limits
+	return p
 }
 
 // Put vector back to the pool.
+//
+// Vector settings resets to defaults.
 func (p *Pool) Put(vec *Vector) {
 	vec.Reset()
+	vec.resetSettings()
 	p.p.Put(vec)
 }
 
@@ -0,0 +1,114 @@
+package xmlvector
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/koykov/vector"
+)
+
+// Pos describes position of the node in the source.
+type Pos struct {
+	// Start is an offset of the first byte of the node (opening "<" for elements, first byte of name for attributes).
+	Start int
+	// End is an offset of the byte following the node (after closing ">" for elements, after closing quote for
+	// attributes).
+	End int
+}
+
+// Source position of the trimmed part of the source.
+type posBase struct {
+	// Count of leading bytes (byte order mark and formatting) trimmed from the source.
+	off int
+	// Count of new lines in trimmed part.
+	line int
+	// Count of runes after last new line in trimmed part.
+	col int
+}
+
+// SetTrackPos enables or disables tracking of nodes positions in the source.
+//
+// Offsets count from the start of the source including byte order mark. Transcoded sources (see SetEncoding) are the
+// exception: their offsets refer to UTF-8 representation without byte order mark.
+//
+// Disabled by default. Setting keeps after Reset() call, but the vector returned to the pool loses it.
+func (vec *Vector) SetTrackPos(value bool) *Vector {
+	vec.trackPos = value
+	return vec
+}
+
+// NodePos returns position of the node in the source.
+//
+// Position is available only for nodes parsed with enabled positions tracking (see SetTrackPos). Offsets are
+// relative to the source passed to parse method, so in case of multiple parsing using one vector, positions of nodes
+// parsed from the previous sources refers to their own sources.
+func (vec *Vector) NodePos(node *vector.Node) (Pos, bool) {
+	i := node.Index()
+	if i >= len(vec.pos) || vec.NodeAt(i) != node || vec.pos[i].End == 0 {
+		return Pos{}, false
+	}
+	pos := vec.pos[i]
+	pos.Start += vec.posBase.off
+	pos.End += vec.posBase.off
+	return pos, true
+}
+
+// LineCol translates offset in the last parsed source to line and column (both 1-based).
+//
+// Column counts in runes. Works only with enabled positions tracking, otherwise returns (0, 0).
+func (vec *Vector) LineCol(offset int) (line, col int) {
+	if !vec.trackPos || vec.SrcLen() == 0 {
+		return
+	}
+	offset -= vec.posBase.off
+	if offset < 0 {
+		offset = 0
+	}
+	src := vec.Src()
+	if offset > len(src) {
+		offset = len(src)
+	}
+	// Find index of the line contains offset.
+	l := sort.SearchInts(vec.lines, offset+1) - 1
+	if l < 0 {
+		return vec.posBase.line + 1, vec.posBase.col + utf8.RuneCount(src[:offset]) + 1
+	}
+	return vec.posBase.line + l + 2, utf8.RuneCount(src[vec.lines[l]:offset]) + 1
+}
+
+// Init positions base and lines table.
+//
+// bom is a length of stripped byte order mark, lead is a trimmed formatting prefix of the source and src is rest of the
+// source. Byte order mark isn't counted by columns.
+func (vec *Vector) initPos(bom int, lead, src []byte) {
+	vec.posBase.off = bom + len(lead)
+	vec.posBase.line = bytes.Count(lead, btNl)
+	vec.posBase.col = len(lead) - bytes.LastIndexByte(lead, '\n') - 1
+	vec.lines = vec.lines[:0]
+	for off := 0; ; {
+		i := bytes.IndexByte(src[off:], '\n')
+		if i == -1 {
+			break
+		}
+		off += i + 1
+		vec.lines = append(vec.lines, off)
+	}
+}
+
+// Register position of node with index i.
+func (vec *Vector) setPos(i, start, end int) {
+	if !vec.trackPos {
+		return
+	}
+	for len(vec.pos) <= i {
+		vec.pos = append(vec.pos, Pos{})
+	}
+	vec.pos[i] = Pos{Start: start, End: end}
+}
+
+func (vec *Vector) resetPos() {
+	vec.pos = vec.pos[:0]
+	vec.posBase = posBase{}
+	vec.lines = vec.lines[:0]
+}
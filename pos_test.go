@@ -0,0 +1,75 @@
+package xmlvector
+
+import (
+	"testing"
+)
+
+func TestPos(t *testing.T) {
+	vec := NewVector().SetTrackPos(true)
+	t.Run("root/object", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		st := getStage(getTBName(t))
+		pos, ok := vec.NodePos(vec.Dot("note.from"))
+		if !ok {
+			t.Fatal("position not found")
+		}
+		if s := string(st.origin[pos.Start:pos.End]); s != "<from>Jani</from>" {
+			t.Error("position mismatch, got", s)
+		}
+		if line, col := vec.LineCol(pos.Start); line != 4 || col != 2 {
+			t.Errorf("line/col mismatch, need 4:2 got %d:%d", line, col)
+		}
+		if line, col := vec.LineCol(pos.End); line != 4 || col != 19 {
+			t.Errorf("line/col mismatch, need 4:19 got %d:%d", line, col)
+		}
+	})
+	t.Run("root/unicode", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		st := getStage(getTBName(t))
+		pos, ok := vec.NodePos(vec.Dot("俄语@լեզու"))
+		if !ok {
+			t.Fatal("position not found")
+		}
+		if s := string(st.origin[pos.Start:pos.End]); s != `լեզու="ռուսերեն"` {
+			t.Error("position mismatch, got", s)
+		}
+		if line, col := vec.LineCol(pos.Start); line != 2 || col != 5 {
+			t.Errorf("line/col mismatch, need 2:5 got %d:%d", line, col)
+		}
+	})
+	t.Run("lead", func(t *testing.T) {
+		src := []byte("\n\n  <root>\n\t<a>1</a>\n</root>")
+		vec.Reset()
+		if err := vec.Parse(src); err != nil {
+			t.Fatal(err)
+		}
+		pos, _ := vec.NodePos(vec.Dot("root"))
+		if line, col := vec.LineCol(pos.Start); pos.Start != 4 || line != 3 || col != 3 {
+			t.Errorf("position mismatch, need 4 (3:3) got %d (%d:%d)", pos.Start, line, col)
+		}
+		pos, _ = vec.NodePos(vec.Dot("root.a"))
+		if line, col := vec.LineCol(pos.Start); string(src[pos.Start:pos.End]) != "<a>1</a>" || line != 4 || col != 2 {
+			t.Errorf("position mismatch, got %s (%d:%d)", src[pos.Start:pos.End], line, col)
+		}
+	})
+	t.Run("bom", func(t *testing.T) {
+		// Offsets refer to the source including byte order mark.
+		src := []byte("\xEF\xBB\xBF <root><a>1</a></root>")
+		vec.Reset()
+		if err := vec.Parse(src); err != nil {
+			t.Fatal(err)
+		}
+		pos, _ := vec.NodePos(vec.Dot("root.a"))
+		if line, col := vec.LineCol(pos.Start); string(src[pos.Start:pos.End]) != "<a>1</a>" || line != 1 || col != 8 {
+			t.Errorf("position mismatch, got %d (%d:%d)", pos.Start, line, col)
+		}
+		// Transcoded source has no byte order mark.
+		vec.Reset()
+		if err := vec.Parse([]byte("\xFF\xFE<\x00r\x00>\x00<\x00a\x00/\x00>\x00<\x00/\x00r\x00>\x00")); err != nil {
+			t.Fatal(err)
+		}
+		if pos, _ = vec.NodePos(vec.Dot("r.a")); pos.Start != 3 || pos.End != 7 {
+			t.Errorf("position mismatch, need 3:7 got %d:%d", pos.Start, pos.End)
+		}
+	})
+}
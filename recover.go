@@ -0,0 +1,123 @@
+package xmlvector
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/koykov/vector"
+)
+
+// Diagnostic describes a fault repaired during parsing in recovery mode.
+type Diagnostic struct {
+	// Offset of the fault in the source, see Vector.ErrorOffset.
+	Offset int
+	// Err describes the fault, eg: ErrUnclosedTag for missing close tag (offset points to the start tag).
+	Err error
+}
+
+// SetRecover enables recovery mode. In this mode common faults are repaired and parsing continues:
+//   - missing close tags are implied by close tag of the parent or by the end of the source (truncated tail);
+//   - stray close tags (that don't match any open element) are skipped;
+//   - malformed elements and attributes are skipped up to the next tag;
+//   - malformed references (see SetStrictRefs) keep as is.
+//
+// Parsing returns error only if no root element was found. Every repaired fault is available using Diagnostics.
+func (vec *Vector) SetRecover(value bool) *Vector {
+	vec.recover = value
+	return vec
+}
+
+// Diagnostics returns faults repaired during the last parsing in recovery mode.
+func (vec *Vector) Diagnostics() []Diagnostic {
+	return vec.diags
+}
+
+// Register diagnostic. Repeated diagnostic (eg: the same EOF reached by nested elements) is ignored.
+func (vec *Vector) diag(offset int, err error) {
+	if l := len(vec.diags); l > 0 && vec.diags[l-1].Offset == offset && vec.diags[l-1].Err == err {
+		return
+	}
+	vec.diags = append(vec.diags, Diagnostic{Offset: offset, Err: err})
+}
+
+// Skip malformed attributes up to the end of the start tag.
+func (vec *Vector) recoverAttr(offset int, err error) (int, bool) {
+	vec.diag(offset, err)
+	src := vec.Src()
+	n := len(src)
+	p := vector.IndexByteAt(src, '>', offset)
+	if p == -1 {
+		return n, false
+	}
+	return p + 1, p > 0 && src[p-1] == '/'
+}
+
+// Skip the rest of malformed element up to the next tag.
+func (vec *Vector) resync(src []byte, n, offset int) (int, bool) {
+	if bytes.HasPrefix(src[offset:], bCTag) {
+		// Close tag of the parent (or stray one).
+		return offset, false
+	}
+	if offset < n && src[offset] == '<' {
+		offset++
+	}
+	if offset >= n {
+		return n, true
+	}
+	if p := vector.IndexByteAt(src, '<', offset); p != -1 {
+		return p, false
+	}
+	return n, true
+}
+
+// Skip close tags that don't match any open element.
+func (vec *Vector) skipStray(src []byte, n, offset int) int {
+	for offset < n && bytes.HasPrefix(src[offset:], bCTag) {
+		p := vector.IndexByteAt(src, '>', offset)
+		if p == -1 {
+			return offset
+		}
+		name := bytes.TrimRight(src[offset+2:p], " \t\r\n")
+		if vec.isOpen(name) {
+			return offset
+		}
+		vec.diag(offset, ErrTagMismatch)
+		offset = p + 1
+		var eof bool
+		if offset, eof = skipCommentAndFmt(src, n, offset); eof {
+			return offset
+		}
+	}
+	return offset
+}
+
+// Check if element with given name is open.
+func (vec *Vector) isOpen(name []byte) bool {
+	src := vec.Src()
+	for i := len(vec.estack) - 1; i >= 0; i-- {
+		tag := src[vec.estack[i].tag0:vec.estack[i].tag1]
+		if bytes.Equal(tag, name) || (vec.html && bytes.EqualFold(tag, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Skip close tag of the element in recovery mode. Missing close tag (end of source or close tag of an ancestor) is
+// implied.
+func (vec *Vector) recoverCTag(src []byte, n, offset int, tag []byte) (int, error) {
+	offset = vec.skipStray(src, n, offset)
+	if p := matchCTag(src, n, offset, tag); p != -1 {
+		return p, nil
+	}
+	if !vec.html || !hasOptionalEnd(tag) {
+		// Point to the start tag of the element.
+		vec.diag(vec.estack[len(vec.estack)-1].start, ErrUnclosedTag)
+	}
+	return offset, nil
+}
+
+// Check if error can't be recovered: limit violation or cancellation of the parsing.
+func isFatal(err error) bool {
+	return isLimitErr(err) || err == context.Canceled || err == context.DeadlineExceeded
+}
@@ -0,0 +1,81 @@
+package xmlvector
+
+import (
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestRecover(t *testing.T) {
+	vec := NewVector()
+	t.Run("recover/feed", func(t *testing.T) {
+		assertParse(t, vec, ErrUnclosedTag, 66)
+
+		vec.SetRecover(true).SetStrictRefs(true)
+		defer func() { vec.SetRecover(false).SetStrictRefs(false) }()
+		vec = assertParse(t, vec, nil, 0)
+		assertType(t, vec, "feed", vector.TypeArray)
+		assertStr(t, vec, "feed.0", "foo", vector.TypeObject)
+		assertStr(t, vec, "feed.1", "bar", vector.TypeObject)
+		assertStr(t, vec, "feed.3.title", "qux", vector.TypeString)
+		assertStr(t, vec, "feed.4", "a &amp b", vector.TypeObject)
+		assertStr(t, vec, "feed.5@id", "6", vector.TypeAttribute)
+		assertStr(t, vec, "feed.5", "trunc", vector.TypeObject)
+
+		expect := []Diagnostic{
+			{52, ErrTagMismatch},
+			{90, ErrBadAttr},
+			{118, ErrUnclosedTag},
+			{154, ErrBadRef},
+			{170, ErrUnclosedTag},
+			{0, ErrUnclosedTag},
+		}
+		diags := vec.Diagnostics()
+		if len(diags) != len(expect) {
+			t.Fatalf("diagnostics mismatch, need %v got %v", expect, diags)
+		}
+		for i := range expect {
+			if diags[i] != expect[i] {
+				t.Errorf("diagnostic #%d mismatch, need %v got %v", i, expect[i], diags[i])
+			}
+		}
+	})
+	t.Run("stray", func(t *testing.T) {
+		vec.SetRecover(true).Reset()
+		defer vec.SetRecover(false)
+		if err := vec.ParseCopyString(`<r><a>1</a></b><c>2</c></r>`); err != nil {
+			t.Fatal(err)
+		}
+		assertStr(t, vec, "r.c", "2", vector.TypeString)
+		if d := vec.Diagnostics(); len(d) != 1 || d[0] != (Diagnostic{11, ErrTagMismatch}) {
+			t.Error("diagnostics mismatch, got", d)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseCopyString(`<r><a></a><b/></r>`); err != nil {
+			t.Fatal(err)
+		}
+		assertType(t, vec, "r.a", vector.TypeObject)
+		if len(vec.Diagnostics()) != 0 {
+			t.Error("unexpected diagnostics")
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		// Close tag cut at the end of the source.
+		for _, src := range []string{`<a><b></b`, `<a><b></`, `<a></a`, `<a><b></b></a`} {
+			vec.Reset()
+			if err := vec.ParseCopyString(src); err != ErrUnclosedTag {
+				t.Errorf("error mismatch for %s, got %v", src, err)
+			}
+			vec.SetRecover(true).Reset()
+			if err := vec.ParseCopyString(src); err != nil {
+				t.Errorf("%s: %v", src, err)
+			}
+			if len(vec.Diagnostics()) == 0 {
+				t.Error("missing diagnostics for", src)
+			}
+			vec.SetRecover(false)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package xmlvector
 
 import (
+	"bytes"
 	"io"
 
 	"github.com/koykov/vector"
@@ -16,25 +17,77 @@ var (
 	btTab   = []byte("\t")
 )
 
-func serialize(w io.Writer, node *vector.Node, depth int, indent bool) (err error) {
-	_, _ = w.Write(bPrologOpen)
-	_ = btAttr(w, node)
-	_, _ = w.Write(bPrologClose)
-	if indent {
-		_, _ = w.Write(btNl)
+func serialize(w io.Writer, node *vector.Node, depth int, indent bool) error {
+	ew := &errWriter{w: w}
+	if !node.Key().CheckBit(flagFragment) {
+		_, _ = ew.Write(bPrologOpen)
+		_ = btAttr(ew, node)
+		_, _ = ew.Write(bPrologClose)
+		if indent {
+			_, _ = ew.Write(btNl)
+		}
 	}
 
-	node.Each(func(idx int, node *vector.Node) {
-		if node.Type() != vector.TypeAttribute {
-			err = serialize1(w, node, depth+1, indent)
-		}
+	eachChild(node, childElem|childComment|childText, func(_ int, child *vector.Node) bool {
+		return serialize1(ew, child, depth+1, indent) == nil
 	})
-	return
+	return ew.err
+}
+
+// Serialization frame of the element: the element, its depth and cursor over its children.
+type serialFrame struct {
+	node     *vector.Node
+	children childCursor
+	depth    int
+	text     bool
 }
 
-func serialize1(w io.Writer, node *vector.Node, depth int, indent bool) (err error) {
+// Serialize the node and its subtree using explicit stack of frames, so depth of the tree is limited by memory only.
+// Stops on the first error of w and returns it.
+func serialize1(w *errWriter, node *vector.Node, depth int, indent bool) error {
+	var stack []serialFrame
+	if f, ok := serializeOpen(w, node, depth, indent); ok {
+		stack = append(stack, f)
+	}
+	for len(stack) > 0 && w.err == nil {
+		top := &stack[len(stack)-1]
+		if child := top.children.next(childElem | childComment); child != nil {
+			if f, ok := serializeOpen(w, child, top.depth+1, indent); ok {
+				stack = append(stack, f)
+			}
+			continue
+		}
+		serializeClose(w, top, indent)
+		stack = stack[:len(stack)-1]
+	}
+	return w.err
+}
+
+// Write the node up to its content. Returns frame and true if the node is an element and must be closed by
+// serializeClose after its children.
+func serializeOpen(w io.Writer, node *vector.Node, depth int, indent bool) (serialFrame, bool) {
+	if node.Key().CheckBit(flagComment) {
+		if indent {
+			writePad(w, depth-1)
+		}
+		_, _ = w.Write(bCommentOpen)
+		_, _ = w.Write(node.Value().Bytes())
+		_, _ = w.Write(bCommentClose)
+		if indent {
+			_, _ = w.Write(btNl)
+		}
+		return serialFrame{}, false
+	}
+	if node.Key().CheckBit(flagText) {
+		writeText(w, node.Value())
+		if indent {
+			_, _ = w.Write(btNl)
+		}
+		return serialFrame{}, false
+	}
 	switch node.Type() {
-	case vector.TypeObject, vector.TypeArray:
+	// Text-only elements without attributes have string type, so they are written with tags as well.
+	case vector.TypeObject, vector.TypeArray, vector.TypeString:
 		if indent {
 			writePad(w, depth-1)
 		}
@@ -43,53 +96,94 @@ func serialize1(w io.Writer, node *vector.Node, depth int, indent bool) (err err
 		_ = btAttr(w, node)
 		_, _ = w.Write(btTagC)
 
+		f := serialFrame{node: node, depth: depth}
 		if node.Value().Len() > 0 && !node.Value().CheckBit(flagAlias) {
-			_, _ = w.Write(node.Value().Bytes())
+			writeText(w, node.Value())
+			f.text = true
 		} else {
 			if indent {
 				_, _ = w.Write(btNl)
 			}
-			node.Each(func(idx int, node *vector.Node) {
-				if node.Type() != vector.TypeAttribute {
-					err = serialize1(w, node, depth+1, indent)
-				}
-			})
-			if indent {
-				writePad(w, depth-1)
-			}
-		}
-
-		_, _ = w.Write(bCTag)
-		_, _ = w.Write(node.Key().Bytes())
-		_, _ = w.Write(btTagC)
-		if indent {
-			_, _ = w.Write(btNl)
+			f.children = newChildCursor(node)
 		}
+		return f, true
 	default:
-		_, _ = w.Write(node.Value().Bytes())
+		writeEscape(w, node.Value().Bytes())
 		if indent {
 			_, _ = w.Write(btNl)
 		}
 	}
-	return
+	return serialFrame{}, false
+}
+
+// Write close tag of the element.
+func serializeClose(w io.Writer, f *serialFrame, indent bool) {
+	if indent && !f.text {
+		writePad(w, f.depth-1)
+	}
+	_, _ = w.Write(bCTag)
+	_, _ = w.Write(f.node.Key().Bytes())
+	_, _ = w.Write(btTagC)
+	if indent {
+		_, _ = w.Write(btNl)
+	}
 }
 
 func btAttr(w io.Writer, node *vector.Node) (err error) {
-	node.Each(func(idx int, node *vector.Node) {
-		if node.Type() == vector.TypeAttribute {
-			_, _ = w.Write(btSpace)
-			_, _ = w.Write(node.Key().Bytes())
-			_, _ = w.Write(btEq)
-			_, _ = w.Write(btQuote)
-			_, _ = w.Write(node.Value().Bytes())
-			_, _ = w.Write(btQuote)
-		}
-	})
+	for _, attr := range Attrs(node) {
+		_, _ = w.Write(btSpace)
+		_, _ = w.Write(attr.Key().Bytes())
+		_, _ = w.Write(btEq)
+		_, _ = w.Write(btQuote)
+		writeEscape(w, attr.Value().Bytes())
+		_, _ = w.Write(btQuote)
+	}
 	return
 }
 
+// Write text value escaped or as CDATA section.
+func writeText(w io.Writer, p *vector.Byteptr) {
+	if !p.CheckBit(flagCDATA) {
+		writeEscape(w, p.Bytes())
+		return
+	}
+	writeCDATA(w, p.Bytes())
+}
+
+// Write p as CDATA section.
+func writeCDATA(w io.Writer, p []byte) {
+	_, _ = w.Write(bCDATAOpen)
+	// Split the section around occurrences of close sequence.
+	for {
+		i := bytes.Index(p, bCDATAClose)
+		if i == -1 {
+			break
+		}
+		_, _ = w.Write(p[:i+2])
+		_, _ = w.Write(bCDATAClose)
+		_, _ = w.Write(bCDATAOpen)
+		p = p[i+2:]
+	}
+	_, _ = w.Write(p)
+	_, _ = w.Write(bCDATAClose)
+}
+
 func writePad(w io.Writer, cnt int) {
 	for i := 0; i < cnt; i++ {
 		_, _ = w.Write(btTab)
 	}
 }
+
+// Writer keeps the first error of the underlying writer and skips the following writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, w.err = w.w.Write(p)
+	return n, w.err
+}
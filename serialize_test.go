@@ -2,6 +2,7 @@ package xmlvector
 
 import (
 	"bytes"
+	"io"
 	"testing"
 )
 
@@ -28,6 +29,35 @@ func TestSerialize(t *testing.T) {
 			t.FailNow()
 		}
 	})
+	t.Run("text", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseString(`<note><to>Tove</to><from a="1">Jani</from><body/></note>`); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_ = vec.Marshal(&buf)
+		if s := buf.String(); s != `<?xml version="1.0"?><note><to>Tove</to><from a="1">Jani</from><body></body></note>` {
+			t.Error("marshal mismatch, got", s)
+		}
+	})
+	t.Run("write", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseCopyString(`<r><!--c--><a x="1">foo</a><b><c/></b></r>`); err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range []func(w io.Writer) error{vec.Marshal, vec.Beautify} {
+			var buf bytes.Buffer
+			_ = fn(&buf)
+			for n := 0; n < buf.Len(); n++ {
+				if err := fn(&failWriter{n: n}); err != errWrite {
+					t.Errorf("error mismatch at %d, got %v", n, err)
+				}
+			}
+			if err := fn(&failWriter{n: buf.Len()}); err != nil {
+				t.Error(err)
+			}
+		}
+	})
 }
 
 func BenchmarkSerialize(b *testing.B) {
@@ -10,11 +10,15 @@ import (
 // Skip close tag of XML element and return offset.
 func skipCTag(src []byte, n, offset int, tag []byte) (int, error) {
 	_ = src[n-1]
-	if offset < n-2 && !bytes.Equal(src[offset:offset+2], bCTag) {
+	if offset+2 > n || !bytes.Equal(src[offset:offset+2], bCTag) {
 		return offset, ErrUnclosedTag
 	}
 	offset += 2
 	offset += len(tag)
+	if offset >= n {
+		// Truncated close tag.
+		return n, ErrUnclosedTag
+	}
 	if src[offset] != '>' {
 		return offset, ErrUnclosedTag
 	}
@@ -124,7 +128,7 @@ func skipFmtTable(src []byte, n, offset int) (int, bool) {
 	if n-offset > 512 {
 		offset, _ = skipFmtBin8(src, n, offset)
 	}
-	for ; skipTable[src[offset]]; offset++ {
+	for ; offset < n && skipTable[src[offset]]; offset++ {
 	}
 	return offset, offset == n
 }
@@ -152,7 +156,7 @@ func init() {
 	skipTable[' '] = true
 	skipTable['\t'] = true
 	skipTable['\n'] = true
-	skipTable['\t'] = true
+	skipTable['\r'] = true
 
 	binNlSpace7Bytes, binSpace8Bytes := []byte("\n       "), []byte("        ")
 	binNlSpace7, binSpace8 = *(*uint64)(unsafe.Pointer(&binNlSpace7Bytes[0])), *(*uint64)(unsafe.Pointer(&binSpace8Bytes[0]))
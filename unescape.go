@@ -2,10 +2,10 @@ package xmlvector
 
 import (
 	"bytes"
-	"strconv"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/koykov/byteconv"
-	"github.com/koykov/vector"
 )
 
 var (
@@ -16,69 +16,151 @@ var (
 	beQuot = []byte("&quot;")
 )
 
+// Max length of entity reference (including ampersand and semicolon).
+const maxRefLen = 40
+
 // Unescape byte array using itself as a destination.
+//
+// Malformed references (and references to unknown entities) keep as is.
 func Unescape(p []byte) []byte {
-	l, i, j, off := len(p), 0, 0, 0
-	for {
-		i = vector.IndexByteAt(p, '&', off)
-		if i < 0 || i+1 == l {
+	return UnescapeEntities(p, nil)
+}
+
+// UnescapeEntities unescapes byte array using itself as a destination. Named entities from ent table are recognized in
+// addition to predefined XML entities.
+func UnescapeEntities(p []byte, ent *Entities) []byte {
+	var (
+		r, w int
+		buf  [utf8.UTFMax]byte
+	)
+	for r < len(p) {
+		i := bytes.IndexByte(p[r:], '&')
+		if i < 0 {
+			w += copy(p[w:], p[r:])
 			break
 		}
-		off = i + 1
-		j = vector.IndexByteAt(p, ';', off)
-		if j < 0 || j <= i {
-			break
+		w += copy(p[w:], p[r:r+i])
+		r += i
+		n, c, s, err := decodeRef(p[r:], ent)
+		switch {
+		case err != nil:
+			p[w] = '&'
+			w, r = w+1, r+1
+		case len(s) > 0:
+			w += copy(p[w:], s)
+			r += n
+		default:
+			z := utf8.EncodeRune(buf[:], c)
+			w += copy(p[w:], buf[:z])
+			r += n
 		}
-		entity := p[i : j+1]
-		if len(entity) < 4 {
-			off = j + 1
-			continue
+	}
+	return p[:w]
+}
+
+// ValidateRefs checks all references in p and returns offset of the first malformed (or unknown) reference and error.
+//
+// Returns -1 and nil if all references are valid.
+func ValidateRefs(p []byte, ent *Entities) (int, error) {
+	var off int
+	for {
+		i := bytes.IndexByte(p[off:], '&')
+		if i < 0 {
+			return -1, nil
 		}
-		switch {
-		case bytes.Equal(entity, beLt):
-			p[i] = '<'
-			copy(p[i+1:], p[j+1:])
-			l -= 3
-		case bytes.Equal(entity, beGt):
-			p[i] = '>'
-			copy(p[i+1:], p[j+1:])
-			l -= 3
-		case bytes.Equal(entity, beAmp):
-			p[i] = '&'
-			copy(p[i+1:], p[j+1:])
-			l -= 4
-		case bytes.Equal(entity, beApos):
-			p[i] = '\''
-			copy(p[i+1:], p[j+1:])
-			l -= 5
-		case bytes.Equal(entity, beQuot):
-			p[i] = '"'
-			copy(p[i+1:], p[j+1:])
-			l -= 5
-		case entity[1] == '#':
-			x := entity[2 : len(entity)-1]
-			u, err := unescNum(x)
-			if err != nil {
-				i++
-				continue
+		off += i
+		n, _, _, err := decodeRef(p[off:], ent)
+		if err != nil {
+			return off, err
+		}
+		off += n
+	}
+}
+
+// Decode reference at the beginning of p.
+//
+// Returns length of the reference and its replacement: rune c for character references and string s for named
+// entities.
+func decodeRef(p []byte, ent *Entities) (n int, c rune, s string, err error) {
+	lim := len(p)
+	if lim > maxRefLen {
+		lim = maxRefLen
+	}
+	j := bytes.IndexByte(p[1:lim], ';') + 1
+	if j <= 1 {
+		err = ErrBadRef
+		return
+	}
+	n = j + 1
+	name := p[1:j]
+	if name[0] != '#' {
+		switch byteconv.B2S(name) {
+		case "lt":
+			s = "<"
+		case "gt":
+			s = ">"
+		case "amp":
+			s = "&"
+		case "apos":
+			s = "'"
+		case "quot":
+			s = `"`
+		default:
+			var ok bool
+			if s, ok = ent.Lookup(name); !ok {
+				err = ErrUnknownEntity
 			}
-			r := rune(u)
-			s := string(r)
-			z := len(s)
-			copy(p[i:], s)
-			copy(p[i+z:], p[j+1:])
-			l -= len(entity) - z
 		}
+		return
+	}
+	c, err = decodeCharRef(name[1:])
+	return
+}
 
-		p = p[:l]
+// Decode character reference without leading "&#" and trailing ";", eg: "x1F600" or "169".
+func decodeCharRef(x []byte) (rune, error) {
+	base := rune(10)
+	if len(x) > 0 && x[0] == 'x' {
+		base, x = 16, x[1:]
+	}
+	if len(x) == 0 {
+		return 0, ErrBadRef
+	}
+	var c rune
+	for i := 0; i < len(x); i++ {
+		var d rune
+		switch b := x[i]; {
+		case b >= '0' && b <= '9':
+			d = rune(b - '0')
+		case base == 16 && b >= 'a' && b <= 'f':
+			d = rune(b-'a') + 10
+		case base == 16 && b >= 'A' && b <= 'F':
+			d = rune(b-'A') + 10
+		default:
+			return 0, ErrBadRef
+		}
+		if c = c*base + d; c > unicode.MaxRune {
+			return 0, ErrBadRef
+		}
+	}
+	if !isXMLChar(c) {
+		return 0, ErrBadRef
 	}
-	return p
+	return c, nil
 }
 
-func unescNum(x []byte) (uint64, error) {
-	if x[0] == 'x' {
-		return strconv.ParseUint(byteconv.B2S(x[1:]), 16, 64)
-	} else {
-		return strconv.ParseUint(byteconv.B2S(x), 10, 64)
+// Check if c matches XML Char production:
+// #x9 | #xA | #xD | [#x20-#xD7FF] | [#xE000-#xFFFD] | [#x10000-#x10FFFF].
+func isXMLChar(c rune) bool {
+	switch {
+	case c == 0x9 || c == 0xA || c == 0xD:
+		return true
+	case c >= 0x20 && c <= 0xD7FF:
+		return true
+	case c >= 0xE000 && c <= 0xFFFD:
+		return true
+	case c >= 0x10000 && c <= unicode.MaxRune:
+		return true
 	}
+	return false
 }
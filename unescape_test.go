@@ -2,11 +2,16 @@ package xmlvector
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/koykov/vector"
 )
 
 type stageUnescape struct {
 	origin, expect []byte
+	ent            *Entities
 }
 
 var (
@@ -45,6 +50,33 @@ Here is some "Text" that I'd like to be "escaped" for XML
 & here is some Swedish: Tack. Varsågod.
 </sometext>`),
 		},
+		"long": {
+			origin: []byte("smile &#x1F600; &#128512;"),
+			expect: []byte("smile 😀 😀"),
+		},
+		"invalid": {
+			origin: []byte("&#; &#x; &#xD800; &#x110000; &#99999999999; &#0; &#x1g; & ;&amp &; &#65;"),
+			expect: []byte("&#; &#x; &#xD800; &#x110000; &#99999999999; &#0; &#x1g; & ;&amp &; A"),
+		},
+		"strict": {
+			origin: []byte("caf&eacute;&nbsp;&mdash; &copy;"),
+			expect: []byte("caf&eacute;&nbsp;&mdash; &copy;"),
+		},
+		"html": {
+			origin: []byte("caf&eacute;&nbsp;&mdash; &AMP; &unknown; &lt;&nvlt;"),
+			expect: []byte("café\u00a0— & &unknown; <<\u20d2"),
+			ent:    HTMLEntities(),
+		},
+		"custom": {
+			origin: []byte("&co; &copy; &nbsp;"),
+			expect: []byte("Co. (c) &nbsp;"),
+			ent: func() *Entities {
+				ent := NewEntities(false)
+				_ = ent.Register("co", "Co.")
+				_ = ent.Register("copy", "(c)")
+				return ent
+			}(),
+		},
 	}
 )
 
@@ -62,7 +94,7 @@ func testUnescape(tb testing.TB, buf []byte) []byte {
 		tb.Fatal("stage not found")
 	}
 	buf = append(buf[:0], st.origin...)
-	buf = Unescape(buf)
+	buf = UnescapeEntities(buf, st.ent)
 	if !bytes.Equal(buf, st.expect) {
 		tb.Error("unescape failed")
 	}
@@ -86,6 +118,72 @@ func TestUnescape(t *testing.T) {
 	t.Run("quot", func(t *testing.T) { testUnescape(t, nil) })
 	t.Run("unicode", func(t *testing.T) { testUnescape(t, nil) })
 	t.Run("mixed", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("long", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("invalid", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("strict", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("html", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("custom", func(t *testing.T) { testUnescape(t, nil) })
+	t.Run("register", func(t *testing.T) {
+		ent := NewEntities(false)
+		if err := ent.Register("a", "long"); err != ErrLongEntity {
+			t.Error("error mismatch, got", err)
+		}
+		if err := ent.Register("", ""); err != ErrEntityName {
+			t.Error("error mismatch, got", err)
+		}
+		name := strings.Repeat("x", maxRefLen-2)
+		if err := ent.Register(name, "y"); err != nil {
+			t.Fatal(err)
+		}
+		if s := string(UnescapeEntities([]byte("&"+name+";"), ent)); s != "y" {
+			t.Error("unescape mismatch, got", s)
+		}
+		if err := ent.Register(name+"x", "y"); err != ErrEntityName {
+			t.Error("error mismatch, got", err)
+		}
+		if err := HTMLEntities().Register("x", "y"); err != ErrReadOnly {
+			t.Error("error mismatch, got", err)
+		}
+		if _, ok := HTMLEntities().Lookup([]byte("x")); ok {
+			t.Error("shared table must be read-only")
+		}
+	})
+	t.Run("vector", func(t *testing.T) {
+		vec := NewVector().SetEntities(HTMLEntities())
+		_ = vec.ParseCopyString(`<a title="&laquo;x&raquo;">caf&eacute;</a>`)
+		assertStr(t, vec, "a", "café", vector.TypeObject)
+		assertStr(t, vec, "a@title", "«x»", vector.TypeAttribute)
+		vec.SetEntities(nil).Reset()
+		_ = vec.ParseCopyString(`<a>caf&eacute;</a>`)
+		assertStr(t, vec, "a", "caf&eacute;", vector.TypeString)
+	})
+	t.Run("strict refs", func(t *testing.T) {
+		vec := NewVector().SetStrictRefs(true)
+		for _, c := range []struct {
+			src string
+			off int
+			err error
+		}{
+			{`<a>&#x1F600;&lt;</a>`, 0, nil},
+			{`<a>x &#xD800;</a>`, 5, ErrBadRef},
+			{`<a b="&#;"/>`, 6, ErrBadRef},
+			{`<a>&copy;</a>`, 3, ErrUnknownEntity},
+			{`<a>&amp</a>`, 3, ErrBadRef},
+		} {
+			vec.Reset()
+			err := vec.ParseCopyString(c.src)
+			if err != c.err {
+				t.Errorf("%s: error mismatch, need %v got %v", c.src, c.err, err)
+			}
+			if err != nil && vec.ErrorOffset() != c.off {
+				t.Errorf("%s: offset mismatch, need %d got %d", c.src, c.off, vec.ErrorOffset())
+			}
+		}
+		vec.SetEntities(HTMLEntities()).Reset()
+		if err := vec.ParseCopyString(`<a>&copy;</a>`); err != nil {
+			t.Error(err)
+		}
+	})
 }
 
 func BenchmarkUnescape(b *testing.B) {
@@ -96,4 +194,80 @@ func BenchmarkUnescape(b *testing.B) {
 	b.Run("quot", func(b *testing.B) { benchUnescape(b) })
 	b.Run("unicode", func(b *testing.B) { benchUnescape(b) })
 	b.Run("mixed", func(b *testing.B) { benchUnescape(b) })
+	b.Run("html", func(b *testing.B) { benchUnescape(b) })
+	b.Run("legacy", func(b *testing.B) {
+		st := getStageUnescape("mixed")
+		var buf []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf = append(buf[:0], st.origin...)
+			buf = unescapeLegacy(buf)
+		}
+	})
+}
+
+// Previous implementation of Unescape, kept for benchmarking.
+func unescapeLegacy(p []byte) []byte {
+	l, i, j, off := len(p), 0, 0, 0
+	for {
+		i = vector.IndexByteAt(p, '&', off)
+		if i < 0 || i+1 == l {
+			break
+		}
+		off = i + 1
+		j = vector.IndexByteAt(p, ';', off)
+		if j < 0 || j <= i {
+			break
+		}
+		entity := p[i : j+1]
+		if len(entity) < 4 {
+			off = j + 1
+			continue
+		}
+		switch {
+		case bytes.Equal(entity, beLt):
+			p[i] = '<'
+			copy(p[i+1:], p[j+1:])
+			l -= 3
+		case bytes.Equal(entity, beGt):
+			p[i] = '>'
+			copy(p[i+1:], p[j+1:])
+			l -= 3
+		case bytes.Equal(entity, beAmp):
+			p[i] = '&'
+			copy(p[i+1:], p[j+1:])
+			l -= 4
+		case bytes.Equal(entity, beApos):
+			p[i] = '\''
+			copy(p[i+1:], p[j+1:])
+			l -= 5
+		case bytes.Equal(entity, beQuot):
+			p[i] = '"'
+			copy(p[i+1:], p[j+1:])
+			l -= 5
+		case entity[1] == '#':
+			x := entity[2 : len(entity)-1]
+			u, err := unescNumLegacy(x)
+			if err != nil {
+				i++
+				continue
+			}
+			r := rune(u)
+			s := string(r)
+			z := len(s)
+			copy(p[i:], s)
+			copy(p[i+z:], p[j+1:])
+			l -= len(entity) - z
+		}
+
+		p = p[:l]
+	}
+	return p
+}
+
+func unescNumLegacy(x []byte) (uint64, error) {
+	if x[0] == 'x' {
+		return strconv.ParseUint(string(x[1:]), 16, 64)
+	}
+	return strconv.ParseUint(string(x), 10, 64)
 }
@@ -1,24 +1,88 @@
 package xmlvector
 
 import (
+	"context"
 	"io"
+	"os"
 
 	"github.com/koykov/byteconv"
 	"github.com/koykov/vector"
 )
 
 const (
-	flagEscape = 0
-	flagAttr   = 1
-	flagAlias  = 2
+	flagEscape  = 0
+	flagAttr    = 1
+	flagAlias   = 2
+	flagCDATA   = 3
+	flagComment = 4
+	// Value contains CR and needs line endings normalization.
+	flagNormEOL = 5
+	// Attribute value contains whitespaces to replace with space.
+	flagNormAttr = 6
+	// Attribute has tokenized type, see parseDTD.
+	flagNormTok = 7
+	// Root node of the fragment (has no prolog).
+	flagFragment = 8
+	// Text node among elements of the fragment.
+	flagText = 9
 )
 
 // Vector implements XML vector parser.
 type Vector struct {
 	vector.Vector
+	// Source positions tracking mode, see SetTrackPos.
+	trackPos bool
+	pos      []Pos
+	posBase  posBase
+	lines    []int
+	// Array detection rules, see SetForceArray and SetNeverArray.
+	arrForce []string
+	arrNever []string
+	arrPath  []byte
+	// Storage of bytes added after parsing.
+	cbuf chunkBuf
+	// Source encoding override (see SetEncoding), detected encoding and buffer of transcoded source.
+	encOverride string
+	enc         string
+	tbuf        []byte
+	// Strict references checking mode, see SetStrictRefs.
+	strictRefs bool
+	// Lenient attributes mode, see SetLenientAttrs.
+	lenientAttrs bool
+	// HTML mode, see SetHTML.
+	html bool
+	// Recovery mode and diagnostics, see SetRecover.
+	recover bool
+	diags   []Diagnostic
+	// Multi-document mode, see SetMultiDoc.
+	multiDoc bool
+	// Tokenized attributes declared in internal DTD subset.
+	dtdTok []dtdAttr
+	// Parsing limits, see SetLimits.
+	limits Limits
+	// Stack of open elements, see parseElement.
+	estack []elemFrame
+	// Files mapped by ParseFileMmap.
+	mmaps [][]byte
+	// Parent indices of nodes, see Parent.
+	parents []int
+	// Compressed source detection mode, see SetDecompress.
+	decompress bool
+	// Context of the parsing and count of checks, see ParseContext.
+	ctx    context.Context
+	csteps int
 }
 
 // NewVector makes new parser.
+//
+// Nodes refer to the vector using uintptr, so they don't keep the vector alive: caller must keep reference to the
+// vector while nodes are in use, eg: during iteration over Descendants(vec.Root()) after the last use of vec.
+//
+// For the same reason the vector must not be allocated on the stack, since the stack may be moved on growth and nodes
+// would refer to the freed memory. Inlining allows escape analysis to keep the vector on the caller's stack, so it's
+// disabled (see TestNewVector).
+//
+//go:noinline
 func NewVector() *Vector {
 	vec := &Vector{}
 	vec.SetBit(vector.FlagInit, true)
@@ -28,38 +92,80 @@ func NewVector() *Vector {
 
 // Parse parses source bytes.
 func (vec *Vector) Parse(s []byte) error {
-	return vec.parse(s, false)
+	return vec.parse(s, false, false)
 }
 
 // ParseString parses source string.
 func (vec *Vector) ParseString(s string) error {
-	return vec.parse(byteconv.S2B(s), false)
+	return vec.parse(byteconv.S2B(s), false, false)
 }
 
 // ParseCopy copies source bytes and parse it.
 func (vec *Vector) ParseCopy(s []byte) error {
-	return vec.parse(s, true)
+	return vec.parse(s, true, false)
 }
 
 // ParseCopyString copies source string and parse it.
 func (vec *Vector) ParseCopyString(s string) error {
-	return vec.parse(byteconv.S2B(s), true)
+	return vec.parse(byteconv.S2B(s), true, false)
+}
+
+// Reset vector data.
+//
+// Vector settings are kept.
+func (vec *Vector) Reset() {
+	vec.Vector.Reset()
+	vec.resetPos()
+	vec.cbuf.reset()
+	vec.enc = ""
+	vec.dtdTok = vec.dtdTok[:0]
+	vec.diags, vec.estack = vec.diags[:0], vec.estack[:0]
+	vec.parents = vec.parents[:0]
+	vec.unmap()
+}
+
+// Reset vector settings to defaults.
+func (vec *Vector) resetSettings() {
+	vec.trackPos = false
+	vec.encOverride = ""
+	vec.strictRefs = false
+	vec.lenientAttrs = false
+	vec.html = false
+	vec.recover = false
+	vec.multiDoc = false
+	vec.limits = Limits{}
+	vec.decompress = false
+	vec.Helper = helper
+	vec.arrForce, vec.arrNever = vec.arrForce[:0], vec.arrNever[:0]
 }
 
 // ParseFile reads file contents and parse it.
 func (vec *Vector) ParseFile(path string) error {
-	err := vec.Vector.ParseFile(path)
-	if err != vector.ErrNotImplement {
+	f, err := os.Open(path)
+	if err != nil {
 		return err
 	}
-	return vec.parse(vec.Buf(), false)
+	defer func() { _ = f.Close() }()
+	return vec.ParseReader(f)
 }
 
 // ParseReader reads source from r and parse it.
+//
+// Compressed source is decompressed if enabled, see SetDecompress.
 func (vec *Vector) ParseReader(r io.Reader) error {
+	if vec.decompress {
+		dr, c, err := decompressReader(r)
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			defer func() { _ = c.Close() }()
+		}
+		r = dr
+	}
 	err := vec.Vector.ParseReader(r)
 	if err != vector.ErrNotImplement {
 		return err
 	}
-	return vec.parse(vec.Buf(), false)
+	return vec.parse(vec.Buf(), false, false)
 }
@@ -35,6 +35,37 @@ func TestProlog(t *testing.T) {
 	})
 }
 
+func TestAttr(t *testing.T) {
+	vec := NewVector()
+	t.Run("attr/spaces", func(t *testing.T) {
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "@version", "1.0", vector.TypeAttribute)
+		assertStr(t, vec, "@encoding", "UTF-8", vector.TypeAttribute)
+		assertStr(t, vec, "root", "text", vector.TypeObject)
+		assertStr(t, vec, "root@title", "Foo", vector.TypeAttribute)
+		assertStr(t, vec, "root@descr", "Bar", vector.TypeAttribute)
+		assertStr(t, vec, "root@x:y", "1", vector.TypeAttribute)
+	})
+	t.Run("attr/badName", func(t *testing.T) {
+		assertParse(t, vec, ErrBadAttr, 6)
+	})
+	t.Run("attr/noSpace", func(t *testing.T) {
+		assertParse(t, vec, ErrBadAttr, 11)
+	})
+	t.Run("attr/lenient", func(t *testing.T) {
+		assertParse(t, vec, ErrBadAttr, 20)
+		vec.SetLenientAttrs(true)
+		defer vec.SetLenientAttrs(false)
+		vec = assertParse(t, vec, nil, 0)
+		assertStr(t, vec, "form.input@type", "checkbox", vector.TypeAttribute)
+		assertStr(t, vec, "form.input@checked", "", vector.TypeAttribute)
+		assertStr(t, vec, "form.input@name", "agree", vector.TypeAttribute)
+		assertStr(t, vec, "form.input@disabled", "", vector.TypeAttribute)
+		assertStr(t, vec, "form.a@href", "/path/to/", vector.TypeAttribute)
+		assertStr(t, vec, "form.a", "link", vector.TypeObject)
+	})
+}
+
 func TestRoot(t *testing.T) {
 	vec := NewVector()
 	t.Run("root/static", func(t *testing.T) {
@@ -169,6 +200,34 @@ func TestReader(t *testing.T) {
 	})
 }
 
+func TestCDATA(t *testing.T) {
+	// Content of CDATA section is character data as is, references aren't recognized inside of it.
+	vec := NewVector()
+	if err := vec.ParseCopyString(`<a><b><![CDATA[&amp; <c>]]></b><d>&amp; x</d></a>`); err != nil {
+		t.Fatal(err)
+	}
+	assertStr(t, vec, "a.b", "&amp; <c>", vector.TypeString)
+	assertStr(t, vec, "a.d", "& x", vector.TypeString)
+	// Section is written back as CDATA, so the document round trips.
+	var buf bytes.Buffer
+	_ = vec.Marshal(&buf)
+	if s := buf.String(); s != `<?xml version="1.0"?><a><b><![CDATA[&amp; <c>]]></b><d>&amp; x</d></a>` {
+		t.Error("marshal mismatch, got", s)
+	}
+}
+
+func TestNewVector(t *testing.T) {
+	// Vector must be allocated on the heap even if it doesn't escape, since nodes refer to it using uintptr and moving
+	// of the stack would make them refer to the freed memory.
+	allocs := testing.AllocsPerRun(100, func() {
+		vec := NewVector()
+		vec.SetRecover(true)
+	})
+	if allocs != 1 {
+		t.Error("vector must be allocated on the heap, allocs", allocs)
+	}
+}
+
 func BenchmarkProlog(b *testing.B) {
 	b.Run("prolog/initial", func(b *testing.B) {
 		bench(b, func(vec *Vector) {
@@ -0,0 +1,143 @@
+package xmlvector
+
+import "github.com/koykov/vector"
+
+// NodeKind describes the kind of XML node reported by walker.
+type NodeKind int
+
+const (
+	// KindElement is an XML element.
+	KindElement NodeKind = iota
+	// KindAttr is an attribute of the element.
+	KindAttr
+	// KindText is a character data (text or CDATA section) of the element.
+	// The node reported with that kind is the element itself, use node.Value() to access data.
+	KindText
+)
+
+// WalkAction is a result of walk callback that controls further walking.
+type WalkAction int
+
+const (
+	// WalkContinue continues walking.
+	WalkContinue WalkAction = iota
+	// WalkSkip skips the rest of the element content.
+	// Returned on element enter it skips attributes, text and children of the element. Returned for attribute or text
+	// it skips remaining attributes, text and children of the owner element. Leave callback calls anyway.
+	WalkSkip
+	// WalkStop stops walking at all.
+	WalkStop
+)
+
+// WalkFn is a callback applied to each visited node.
+type WalkFn func(node *vector.Node, info *WalkInfo) WalkAction
+
+// WalkInfo describes position of the visited node in the tree.
+type WalkInfo struct {
+	// Kind of the visited node.
+	Kind NodeKind
+	// Depth of the node relative to the walk start. Attributes and text have depth of the owner element plus one.
+	Depth int
+
+	stack  []*vector.Node
+	frames []walkFrame
+}
+
+// Walk frame of the element: the element and cursor over its children.
+type walkFrame struct {
+	node     *vector.Node
+	children childCursor
+}
+
+// Path returns list of elements from the walk start to the visited element (or to the owner element for attributes
+// and text).
+//
+// Returned slice is valid only until callback returns.
+func (info *WalkInfo) Path() []*vector.Node {
+	return info.stack
+}
+
+// Walk walks the subtree of the node in document order.
+//
+// Enter calls for each element, attribute and text, leave calls for elements after their content was walked. Both
+// callbacks are optional.
+func Walk(node *vector.Node, enter, leave WalkFn) {
+	var info WalkInfo
+	walk(node, &info, enter, leave)
+}
+
+// Walk walks all root elements of the document. See Walk function.
+func (vec *Vector) Walk(enter, leave WalkFn) {
+	var info WalkInfo
+	for _, node := range Children(vec.Root()) {
+		if walk(node, &info, enter, leave) == WalkStop {
+			return
+		}
+	}
+}
+
+// Walk the subtree using explicit stack of frames, so depth of the tree is limited by memory only.
+func walk(node *vector.Node, info *WalkInfo, enter, leave WalkFn) WalkAction {
+	base := len(info.frames)
+	defer func() {
+		info.stack, info.frames = info.stack[:base], info.frames[:base]
+	}()
+	if walkEnter(node, info, enter) == WalkStop {
+		return WalkStop
+	}
+	for len(info.frames) > base {
+		top := len(info.frames) - 1
+		if child := info.frames[top].children.next(childElem); child != nil {
+			if walkEnter(child, info, enter) == WalkStop {
+				return WalkStop
+			}
+			continue
+		}
+		if leave != nil {
+			info.Kind, info.Depth = KindElement, top
+			if leave(info.frames[top].node, info) == WalkStop {
+				return WalkStop
+			}
+		}
+		info.stack, info.frames = info.stack[:top], info.frames[:top]
+	}
+	return WalkContinue
+}
+
+// Enter the element, its attributes and text, and push frame of the element unless walking was stopped.
+func walkEnter(node *vector.Node, info *WalkInfo, enter WalkFn) WalkAction {
+	depth := len(info.stack)
+	info.stack = append(info.stack, node)
+	act := WalkContinue
+	if enter != nil {
+		info.Kind, info.Depth = KindElement, depth
+		if act = enter(node, info); act == WalkContinue {
+			act = walkData(node, info, enter)
+		}
+	}
+	if act == WalkStop {
+		return act
+	}
+	frame := walkFrame{node: node}
+	if act == WalkContinue {
+		frame.children = newChildCursor(node)
+	}
+	info.frames = append(info.frames, frame)
+	return act
+}
+
+// Enter attributes and text of the element.
+func walkData(node *vector.Node, info *WalkInfo, enter WalkFn) (act WalkAction) {
+	depth := len(info.stack)
+	for _, attr := range Attrs(node) {
+		info.Kind, info.Depth = KindAttr, depth
+		if act = enter(attr, info); act != WalkContinue {
+			return
+		}
+	}
+	if isText(node) {
+		info.Kind, info.Depth = KindText, depth
+		act = enter(node, info)
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package xmlvector
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+type walkTrace struct {
+	buf        []string
+	skip, stop string
+}
+
+func (w *walkTrace) enter(node *vector.Node, info *WalkInfo) WalkAction {
+	var s string
+	switch info.Kind {
+	case KindElement:
+		s = "+" + node.KeyString()
+	case KindAttr:
+		s = "@" + node.KeyString()
+	case KindText:
+		s = "#" + node.String()
+	}
+	w.buf = append(w.buf, strconv.Itoa(info.Depth)+s)
+	if info.Kind == KindElement && len(info.Path()) != info.Depth+1 {
+		w.buf = append(w.buf, "bad path")
+	}
+	switch node.KeyString() {
+	case w.skip:
+		return WalkSkip
+	case w.stop:
+		return WalkStop
+	}
+	return WalkContinue
+}
+
+func (w *walkTrace) leave(node *vector.Node, info *WalkInfo) WalkAction {
+	w.buf = append(w.buf, strconv.Itoa(info.Depth)+"-"+node.KeyString())
+	return WalkContinue
+}
+
+func (w *walkTrace) String() string {
+	return strings.Join(w.buf, " ")
+}
+
+func TestWalk(t *testing.T) {
+	vec := NewVector()
+	t.Run("root/object", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		w := walkTrace{}
+		vec.Walk(w.enter, w.leave)
+		if s := w.String(); s != "0+note 1+to 2#Tove 1-to 1+from 2#Jani 1-from 1+heading 2#Reminder 1-heading "+
+			"1+body 2#Don't forget me this weekend! 1-body 0-note" {
+			t.Error("walk mismatch, got", s)
+		}
+
+		w = walkTrace{skip: "from", stop: "heading"}
+		Walk(vec.Dot("note"), w.enter, w.leave)
+		if s := w.String(); s != "0+note 1+to 2#Tove 1-to 1+from 1-from 1+heading" {
+			t.Error("skip/stop walk mismatch, got", s)
+		}
+	})
+	t.Run("root/attr", func(t *testing.T) {
+		assertParse(t, vec, nil, 0)
+		w := walkTrace{}
+		vec.Walk(w.enter, nil)
+		if s := w.String(); s != "0+root 1@title 1@descr 1@arg0 1@arg1" {
+			t.Error("walk mismatch, got", s)
+		}
+
+		w = walkTrace{skip: "descr"}
+		vec.Walk(w.enter, w.leave)
+		if s := w.String(); s != "0+root 1@title 1@descr 0-root" {
+			t.Error("skip walk mismatch, got", s)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package xmlvector
+
+import (
+	"io"
+	"strings"
+
+	"github.com/koykov/byteconv"
+)
+
+// Writer writes XML document directly to io.Writer without building a vector.
+//
+// Writer tracks open elements, so End closes the current element and Close closes all of them. Start tag remains open
+// after StartElement until the first content, so attributes may be added using Attr. Text and attribute values are
+// escaped, comments and CDATA sections are written as is. Names of elements and attributes must be valid XML names
+// (ErrBadName) and comments must not contain "--" (ErrBadComment).
+//
+// The first error (of output or misuse) stops writing and returns by all the following calls.
+type Writer struct {
+	w io.Writer
+	// Pretty-print mode, see SetIndent.
+	indent bool
+	// Names of open elements stored sequentially and their offsets.
+	names []byte
+	stack []int
+	// Start tag of the current element isn't closed yet.
+	pending bool
+	// Current element contains text.
+	text bool
+	// Root element is already written.
+	root bool
+	err  error
+}
+
+// NewWriter makes new writer over w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// SetIndent enables pretty-print mode using the same style as Beautify.
+func (w *Writer) SetIndent(indent bool) *Writer {
+	w.indent = indent
+	return w
+}
+
+// Prolog writes prolog instruction with attributes given as name/value pairs.
+//
+// Prolog without attributes gets default version="1.0".
+func (w *Writer) Prolog(pairs ...string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(pairs)%2 != 0 {
+		return w.fail(ErrBadAttr)
+	}
+	if w.root {
+		return w.fail(ErrAttrOrder)
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		if !isName(pairs[i]) {
+			return w.fail(ErrBadName)
+		}
+	}
+	w.write(bPrologOpen)
+	if len(pairs) == 0 {
+		pairs = defaultProlog
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		w.attr(pairs[i], pairs[i+1])
+	}
+	w.write(bPrologClose)
+	if w.indent {
+		w.write(btNl)
+	}
+	return w.err
+}
+
+var defaultProlog = []string{"version", "1.0"}
+
+// StartElement opens new element inside the current one.
+func (w *Writer) StartElement(name string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !isName(name) {
+		return w.fail(ErrBadName)
+	}
+	if len(w.stack) == 0 {
+		if w.root {
+			return w.fail(ErrMultiRoot)
+		}
+		w.root = true
+	}
+	w.closeStart(true)
+	if w.indent {
+		w.pad(len(w.stack))
+	}
+	w.write(btTagO)
+	w.writeString(name)
+	w.stack = append(w.stack, len(w.names))
+	w.names = append(w.names, name...)
+	w.pending, w.text = true, false
+	return w.err
+}
+
+// Attr writes attribute of the current element. Must be called before element's content.
+func (w *Writer) Attr(name, value string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return w.fail(ErrNoOpenElem)
+	}
+	if !w.pending {
+		return w.fail(ErrAttrOrder)
+	}
+	if !isName(name) {
+		return w.fail(ErrBadName)
+	}
+	w.attr(name, value)
+	return w.err
+}
+
+// Text writes escaped text of the current element.
+func (w *Writer) Text(text string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return w.fail(ErrNoOpenElem)
+	}
+	w.closeStart(false)
+	writeEscape(w, byteconv.S2B(text))
+	w.text = true
+	return w.err
+}
+
+// CDATA writes text of the current element as CDATA section.
+func (w *Writer) CDATA(text string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return w.fail(ErrNoOpenElem)
+	}
+	w.closeStart(false)
+	writeCDATA(w, byteconv.S2B(text))
+	w.text = true
+	return w.err
+}
+
+// Comment writes comment inside the current element (or on document level if no element is open).
+func (w *Writer) Comment(text string) error {
+	if w.err != nil {
+		return w.err
+	}
+	// Comment can't contain "--" and can't end with "-", since it would be followed by "-->".
+	if strings.Contains(text, "--") || strings.HasSuffix(text, "-") {
+		return w.fail(ErrBadComment)
+	}
+	w.closeStart(true)
+	if w.indent {
+		w.pad(len(w.stack))
+	}
+	w.write(bCommentOpen)
+	w.writeString(text)
+	w.write(bCommentClose)
+	if w.indent {
+		w.write(btNl)
+	}
+	return w.err
+}
+
+// End closes the current element.
+func (w *Writer) End() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return w.fail(ErrNoOpenElem)
+	}
+	depth := len(w.stack) - 1
+	off := w.stack[depth]
+	name := w.names[off:]
+	switch {
+	case w.pending:
+		w.closeStart(true)
+		fallthrough
+	case !w.text:
+		if w.indent {
+			w.pad(depth)
+		}
+	}
+	w.write(bCTag)
+	w.write(name)
+	w.write(btTagC)
+	if w.indent {
+		w.write(btNl)
+	}
+	w.stack, w.names = w.stack[:depth], w.names[:off]
+	w.text = false
+	return w.err
+}
+
+// EndElement closes the current element and checks if its name matches name.
+func (w *Writer) EndElement(name string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.stack) == 0 {
+		return w.fail(ErrNoOpenElem)
+	}
+	if string(w.names[w.stack[len(w.stack)-1]:]) != name {
+		return w.fail(ErrTagMismatch)
+	}
+	return w.End()
+}
+
+// Depth returns count of open elements.
+func (w *Writer) Depth() int {
+	return len(w.stack)
+}
+
+// Close closes all open elements. Underlying writer remains open.
+func (w *Writer) Close() error {
+	for w.err == nil && len(w.stack) > 0 {
+		_ = w.End()
+	}
+	return w.err
+}
+
+// Err returns the first error occurred during writing.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Reset writer to write new document to dst. Settings are kept.
+func (w *Writer) Reset(dst io.Writer) {
+	w.w = dst
+	w.names, w.stack = w.names[:0], w.stack[:0]
+	w.pending, w.text, w.root, w.err = false, false, false, nil
+}
+
+// Write writes raw bytes to the underlying writer as is.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	var n int
+	n, w.err = w.w.Write(p)
+	return n, w.err
+}
+
+// Close start tag of the current element if it's still open. nl allows line break in pretty-print mode.
+func (w *Writer) closeStart(nl bool) {
+	if !w.pending {
+		return
+	}
+	w.write(btTagC)
+	if nl && w.indent {
+		w.write(btNl)
+	}
+	w.pending = false
+}
+
+func (w *Writer) attr(name, value string) {
+	w.write(btSpace)
+	w.writeString(name)
+	w.write(btEq)
+	w.write(btQuote)
+	writeEscape(w, byteconv.S2B(value))
+	w.write(btQuote)
+}
+
+func (w *Writer) pad(cnt int) {
+	for i := 0; i < cnt; i++ {
+		w.write(btTab)
+	}
+}
+
+func (w *Writer) write(p []byte) {
+	_, _ = w.Write(p)
+}
+
+func (w *Writer) writeString(s string) {
+	_, _ = w.Write(byteconv.S2B(s))
+}
+
+func (w *Writer) fail(err error) error {
+	w.err = err
+	return err
+}
@@ -0,0 +1,166 @@
+package xmlvector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestWriter(t *testing.T) {
+	// Stream parsed node to the writer.
+	var writeNode func(w *Writer, node *vector.Node)
+	writeNode = func(w *Writer, node *vector.Node) {
+		_ = w.StartElement(node.KeyString())
+		for _, attr := range Attrs(node) {
+			_ = w.Attr(attr.KeyString(), attr.String())
+		}
+		if isText(node) {
+			_ = w.Text(node.String())
+		}
+		for _, child := range Children(node) {
+			writeNode(w, child)
+		}
+		_ = w.EndElement(node.KeyString())
+	}
+	assertWriter := func(t *testing.T, indent bool) {
+		vec := NewVector()
+		assertParse(t, vec, nil, 0)
+		var buf bytes.Buffer
+		w := NewWriter(&buf).SetIndent(indent)
+		var pairs []string
+		for _, attr := range Attrs(vec.Root()) {
+			pairs = append(pairs, attr.KeyString(), attr.String())
+		}
+		_ = w.Prolog(pairs...)
+		for _, child := range Children(vec.Root()) {
+			writeNode(w, child)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		st := getStage(getTBName(t))
+		expect := st.flat
+		if indent {
+			expect = st.fmt
+		}
+		if !bytes.Equal(buf.Bytes(), expect) {
+			t.Errorf("output mismatch, need\n%s\ngot\n%s", expect, buf.String())
+		}
+	}
+	t.Run("serialize/beautify", func(t *testing.T) { assertWriter(t, true) })
+	t.Run("serialize/marshal", func(t *testing.T) { assertWriter(t, false) })
+	t.Run("auto close", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		_ = w.Prolog()
+		_ = w.StartElement("a")
+		_ = w.Attr("x", `1 "2"`)
+		_ = w.Comment("c")
+		_ = w.StartElement("b")
+		_ = w.Text("x < y")
+		_ = w.StartElement("c")
+		_ = w.CDATA("]]>")
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expect := `<?xml version="1.0"?><a x="1 &quot;2&quot;"><!--c--><b>x &lt; y<c><![CDATA[]]]]><![CDATA[>]]></c></b></a>`
+		if buf.String() != expect {
+			t.Error("output mismatch, got", buf.String())
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		_ = w.StartElement("a")
+		if err := w.EndElement("b"); err != ErrTagMismatch {
+			t.Error("error mismatch, got", err)
+		}
+		if err := w.End(); err != ErrTagMismatch {
+			t.Error("error must be sticky, got", err)
+		}
+		w.Reset(&buf)
+		_ = w.StartElement("a")
+		_ = w.Text("foo")
+		if err := w.Attr("id", "1"); err != ErrAttrOrder {
+			t.Error("error mismatch, got", err)
+		}
+		w.Reset(&buf)
+		_ = w.StartElement("a")
+		_ = w.End()
+		if err := w.StartElement("b"); err != ErrMultiRoot {
+			t.Error("error mismatch, got", err)
+		}
+		w.Reset(&buf)
+		if err := w.Text("foo"); err != ErrNoOpenElem {
+			t.Error("error mismatch, got", err)
+		}
+	})
+	t.Run("names", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		for _, name := range []string{"", "1a", "a b", "a<b", "a>"} {
+			w.Reset(&buf)
+			if err := w.StartElement(name); err != ErrBadName {
+				t.Errorf("element %q error mismatch, got %v", name, err)
+			}
+			w.Reset(&buf)
+			_ = w.StartElement("a")
+			if err := w.Attr(name, "1"); err != ErrBadName {
+				t.Errorf("attribute %q error mismatch, got %v", name, err)
+			}
+			w.Reset(&buf)
+			if err := w.Prolog("version", "1.0", name, "1"); err != ErrBadName {
+				t.Errorf("prolog %q error mismatch, got %v", name, err)
+			}
+		}
+		w.Reset(&buf)
+		_ = w.StartElement("x:a-b.c_d")
+		if err := w.Attr("xml:lang", "en"); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("comment", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		for _, text := range []string{"a--b", "--", "a-", "-"} {
+			w.Reset(&buf)
+			if err := w.Comment(text); err != ErrBadComment {
+				t.Errorf("comment %q error mismatch, got %v", text, err)
+			}
+		}
+		buf.Reset()
+		w.Reset(&buf)
+		if err := w.Comment(" a-b - c "); err != nil {
+			t.Error(err)
+		}
+		if buf.String() != "<!-- a-b - c -->" {
+			t.Error("output mismatch, got", buf.String())
+		}
+	})
+}
+
+func BenchmarkWriter(b *testing.B) {
+	b.ReportAllocs()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		w.Reset(&buf)
+		_ = w.Prolog()
+		_ = w.StartElement("CATALOG")
+		for j := 0; j < 26; j++ {
+			_ = w.StartElement("CD")
+			_ = w.StartElement("TITLE")
+			_ = w.Text("Empire Burlesque")
+			_ = w.End()
+			_ = w.StartElement("YEAR")
+			_ = w.Text("1985")
+			_ = w.End()
+			_ = w.End()
+		}
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}